@@ -0,0 +1,276 @@
+package boundschecking
+
+import (
+	"math/big"
+)
+
+// Giving up is always safe since it only means a check is not proven
+const maxEliminationRules = 256
+
+// A rule means the sum of values[i] * sumGroups[i] is at least zero where a
+// nil sum group stands for the constant 1. Every sum group in a rule is a
+// known equation so its value is also at least zero
+type boundingRule struct {
+	sumGroups []*SumGroup
+	values    []*big.Rat
+}
+
+type BoundingRules struct {
+	rules []boundingRule
+}
+
+type eliminationRule struct {
+	coefficients map[*SumGroup]*big.Rat
+	constant     *big.Rat
+}
+
+func (boundingRules *BoundingRules) Copy() BoundingRules {
+	var rulesCopy = make([]boundingRule, len(boundingRules.rules))
+	copy(rulesCopy, boundingRules.rules)
+	return BoundingRules{rulesCopy}
+}
+
+func (boundingRules *BoundingRules) Contains(sumGroup *SumGroup) bool {
+	for _, rule := range boundingRules.rules {
+		for _, other := range rule.sumGroups {
+			if other == sumGroup {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (boundingRules *BoundingRules) Add(sumGroups []*SumGroup, values []*big.Rat) {
+	if !boundingRules.IsBounded(sumGroups, values) {
+		boundingRules.rules = append(boundingRules.rules, boundingRule{sumGroups, values})
+	}
+}
+
+// Adds the rule that equation is equal to the sum of values[i] * sumGroups[i]
+func (boundingRules *BoundingRules) AddEquality(equation *SumGroup, sumGroups []*SumGroup, values []*big.Rat) {
+	var negatedValues []*big.Rat = nil
+
+	for _, value := range values {
+		negatedValues = append(negatedValues, new(big.Rat).Neg(value))
+	}
+
+	boundingRules.rules = append(boundingRules.rules,
+		boundingRule{append([]*SumGroup{equation}, sumGroups...), append([]*big.Rat{big.NewRat(-1, 1)}, values...)},
+		boundingRule{append([]*SumGroup{equation}, sumGroups...), append([]*big.Rat{big.NewRat(1, 1)}, negatedValues...)},
+	)
+}
+
+func (rule boundingRule) sharesVariables(variables map[*SumGroup]bool) bool {
+	for _, sumGroup := range rule.sumGroups {
+		if sumGroup != nil && variables[sumGroup] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newEliminationRule(sumGroups []*SumGroup, values []*big.Rat, scale int64) eliminationRule {
+	var result = eliminationRule{
+		make(map[*SumGroup]*big.Rat),
+		new(big.Rat),
+	}
+
+	for index, sumGroup := range sumGroups {
+		var value = new(big.Rat).Mul(values[index], big.NewRat(scale, 1))
+
+		if sumGroup == nil {
+			result.constant.Add(result.constant, value)
+		} else {
+			var existing, ok = result.coefficients[sumGroup]
+
+			if ok {
+				existing.Add(existing, value)
+			} else {
+				result.coefficients[sumGroup] = value
+			}
+		}
+	}
+
+	return result
+}
+
+// Checks if the sum of values[i] * sumGroups[i] is at least zero using the
+// known rules. It does so by adding the opposite, that the sum is at most -1,
+// and eliminating variables until a contradiction is found
+func (boundingRules *BoundingRules) IsBounded(sumGroups []*SumGroup, values []*big.Rat) bool {
+	var negatedCheck = newEliminationRule(sumGroups, values, -1)
+	negatedCheck.constant.Sub(negatedCheck.constant, big.NewRat(1, 1))
+
+	var rules = []eliminationRule{negatedCheck}
+	var variables = make(map[*SumGroup]bool)
+
+	for sumGroup := range negatedCheck.coefficients {
+		variables[sumGroup] = true
+	}
+
+	// only rules that share variables with the check can change the result
+	var used = make([]bool, len(boundingRules.rules))
+	var foundRule = true
+
+	for foundRule {
+		foundRule = false
+
+		for index, rule := range boundingRules.rules {
+			if !used[index] && rule.sharesVariables(variables) {
+				used[index] = true
+				foundRule = true
+
+				var eliminationRule = newEliminationRule(rule.sumGroups, rule.values, 1)
+				rules = append(rules, eliminationRule)
+
+				for sumGroup := range eliminationRule.coefficients {
+					variables[sumGroup] = true
+				}
+			}
+		}
+	}
+
+	for sumGroup := range variables {
+		rules = append(rules, eliminationRule{
+			map[*SumGroup]*big.Rat{sumGroup: big.NewRat(1, 1)},
+			new(big.Rat),
+		})
+	}
+
+	return isContradiction(rules)
+}
+
+// Checks if no values satisfy all of the rules at once. Checks only look at
+// the rules they share variables with so this can find contradictions they
+// didn't
+func (boundingRules *BoundingRules) HasContradiction() bool {
+	var rules []eliminationRule = nil
+	var variables = make(map[*SumGroup]bool)
+
+	for _, rule := range boundingRules.rules {
+		var eliminationRule = newEliminationRule(rule.sumGroups, rule.values, 1)
+		rules = append(rules, eliminationRule)
+
+		for sumGroup := range eliminationRule.coefficients {
+			variables[sumGroup] = true
+		}
+	}
+
+	for sumGroup := range variables {
+		rules = append(rules, eliminationRule{
+			map[*SumGroup]*big.Rat{sumGroup: big.NewRat(1, 1)},
+			new(big.Rat),
+		})
+	}
+
+	return isContradiction(rules)
+}
+
+func isContradiction(rules []eliminationRule) bool {
+	for {
+		var variable *SumGroup = nil
+		var bestCost = -1
+
+		for _, rule := range rules {
+			if len(rule.coefficients) == 0 && rule.constant.Sign() < 0 {
+				return true
+			}
+
+			for sumGroup := range rule.coefficients {
+				var cost = eliminationCost(rules, sumGroup)
+
+				if bestCost == -1 || cost < bestCost {
+					variable = sumGroup
+					bestCost = cost
+				}
+			}
+		}
+
+		if variable == nil || bestCost > maxEliminationRules {
+			return false
+		}
+
+		rules = eliminateVariable(rules, variable)
+	}
+}
+
+func eliminationCost(rules []eliminationRule, variable *SumGroup) int {
+	var positive = 0
+	var negative = 0
+
+	for _, rule := range rules {
+		coefficient, ok := rule.coefficients[variable]
+
+		if ok && coefficient.Sign() > 0 {
+			positive = positive + 1
+		} else if ok && coefficient.Sign() < 0 {
+			negative = negative + 1
+		}
+	}
+
+	return len(rules) + positive*negative - positive - negative
+}
+
+func eliminateVariable(rules []eliminationRule, variable *SumGroup) []eliminationRule {
+	var result []eliminationRule = nil
+	var positive []eliminationRule = nil
+	var negative []eliminationRule = nil
+
+	for _, rule := range rules {
+		coefficient, ok := rule.coefficients[variable]
+
+		if !ok || coefficient.Sign() == 0 {
+			result = append(result, rule)
+		} else if coefficient.Sign() > 0 {
+			positive = append(positive, rule)
+		} else {
+			negative = append(negative, rule)
+		}
+	}
+
+	for _, positiveRule := range positive {
+		for _, negativeRule := range negative {
+			var positiveScale = new(big.Rat).Neg(negativeRule.coefficients[variable])
+			var negativeScale = positiveRule.coefficients[variable]
+
+			var combined = eliminationRule{
+				make(map[*SumGroup]*big.Rat),
+				new(big.Rat).Add(
+					new(big.Rat).Mul(positiveRule.constant, positiveScale),
+					new(big.Rat).Mul(negativeRule.constant, negativeScale),
+				),
+			}
+
+			addScaledCoefficients(combined.coefficients, positiveRule.coefficients, positiveScale, variable)
+			addScaledCoefficients(combined.coefficients, negativeRule.coefficients, negativeScale, variable)
+
+			result = append(result, combined)
+		}
+	}
+
+	return result
+}
+
+func addScaledCoefficients(into map[*SumGroup]*big.Rat, from map[*SumGroup]*big.Rat, scale *big.Rat, skip *SumGroup) {
+	for sumGroup, coefficient := range from {
+		if sumGroup == skip {
+			continue
+		}
+
+		var scaled = new(big.Rat).Mul(coefficient, scale)
+		existing, ok := into[sumGroup]
+
+		if ok {
+			existing.Add(existing, scaled)
+
+			if existing.Sign() == 0 {
+				delete(into, sumGroup)
+			}
+		} else if scaled.Sign() != 0 {
+			into[sumGroup] = scaled
+		}
+	}
+}
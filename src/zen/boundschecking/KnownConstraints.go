@@ -1,6 +1,7 @@
 package boundschecking
 
 import (
+	"math/big"
 	"sort"
 	"strings"
 	"zen/zmath"
@@ -25,31 +26,31 @@ type equationColumnInfo struct {
 type KnownConstraints struct {
 	equationColumns        []equationColumnInfo
 	productGroupRows       map[uint32]productGroupEntry
-	equationTransformation *zmath.Matrixi64
-	sumSpaceBoundingVolume ConvexNDVolume
+	equationTransformation *zmath.MatrixRat
+	boundingRules          BoundingRules
 }
 
 func NewKnownConstraints() *KnownConstraints {
 	var result = &KnownConstraints{
 		make([]equationColumnInfo, 0),
 		make(map[uint32]productGroupEntry, 0),
-		zmath.NewMatrixi64(1, 1),
-		ConvexNDVolume{},
+		zmath.NewMatrixRat(1, 1),
+		BoundingRules{},
 	}
 
-	result.equationTransformation.InitialzeIdentityi64()
+	result.equationTransformation.InitializeIdentity()
 
 	return result
 }
 
-func (constraints *KnownConstraints) negateColumnVector(columnVector *zmath.Matrixi64) *zmath.Matrixi64 {
-	var result = columnVector.Scalei64(zmath.Ri64Fromi64(-1))
-	result.SetEntryi64(0, 0, zmath.SubRi64(result.GetEntryi64(0, 0), zmath.Ri64_1()))
+func (constraints *KnownConstraints) negateColumnVector(columnVector *zmath.MatrixRat) *zmath.MatrixRat {
+	var result = columnVector.Scale(big.NewRat(-1, 1))
+	result.SetEntry(0, 0, new(big.Rat).Sub(result.GetEntry(0, 0), big.NewRat(1, 1)))
 	return result
 }
 
-func (constraints *KnownConstraints) checkColumnVector(columnVector *zmath.Matrixi64) (result CheckResult, err error) {
-	transformedVector, err := constraints.equationTransformation.Muli64(columnVector)
+func (constraints *KnownConstraints) checkColumnVector(columnVector *zmath.MatrixRat) (result CheckResult, err error) {
+	transformedVector, err := constraints.equationTransformation.Mul(columnVector)
 
 	if err != nil {
 		return CheckResult{
@@ -57,10 +58,21 @@ func (constraints *KnownConstraints) checkColumnVector(columnVector *zmath.Matri
 		}, err
 	}
 
+	// nothing is known about a column without an equation so it can be any
+	// value. This has to be found before the checks below stop at the first
+	// row that doesn't hold
+	for index := uint32(1); index < transformedVector.Rows; index = index + 1 {
+		if constraints.equationColumns[index-1].sumGroup == nil && transformedVector.GetEntry(index, 0).Sign() != 0 {
+			return CheckResult{
+				false,
+			}, nil
+		}
+	}
+
 	var isTrue = true
 
 	for index := uint32(0); isTrue && index < transformedVector.Rows; index = index + 1 {
-		entryValue := transformedVector.GetEntryi64(index, 0).Numerator
+		entryValue := transformedVector.GetEntry(index, 0).Sign()
 
 		var sumGroup *SumGroup = nil
 
@@ -72,13 +84,11 @@ func (constraints *KnownConstraints) checkColumnVector(columnVector *zmath.Matri
 			if entryValue < 0 {
 				isTrue = false
 			}
-		} else if sumGroup == nil && entryValue != 0 {
-			isTrue = false
 		} else if entryValue < 0 && !constraints.equationColumns[index-1].isZero {
 			isTrue = false
 		}
 
-		if !isTrue && constraints.sumSpaceBoundingVolume.GetMaybeSumGroupIndex(sumGroup) == -1 {
+		if !isTrue && sumGroup != nil && !constraints.boundingRules.Contains(sumGroup) {
 			return CheckResult{
 				false,
 			}, nil
@@ -88,7 +98,7 @@ func (constraints *KnownConstraints) checkColumnVector(columnVector *zmath.Matri
 	if !isTrue {
 		sumGroups, values := constraints.extractVolumeValues(transformedVector)
 
-		if !constraints.sumSpaceBoundingVolume.IsBounded(sumGroups, values) {
+		if !constraints.boundingRules.IsBounded(sumGroups, values) {
 			return CheckResult{
 				false,
 			}, nil
@@ -111,12 +121,31 @@ func (constraints *KnownConstraints) CheckSumGroup(equation *SumGroup) (result C
 	}
 
 	columnVector := constraints.extractColumnVector(equation)
+
+	if columnVector == nil {
+		return CheckResult{
+			false,
+		}, nil
+	}
+
 	return constraints.checkColumnVector(columnVector)
 }
 
+// Checks if the known equations contradict each other so that no values
+// satisfy all of them
+func (constraints *KnownConstraints) HasContradiction() bool {
+	return constraints.boundingRules.HasContradiction()
+}
+
 func (constraints *KnownConstraints) InsertSumGroup(equation *SumGroup) (isValid bool, err error) {
 	columnVector := constraints.extractColumnVector(equation)
 
+	// an equation with a scalar that overflowed is left out, knowing less is
+	// still correct
+	if columnVector == nil {
+		return true, nil
+	}
+
 	var contradictionCheckVector = constraints.negateColumnVector(columnVector)
 
 	contradictionCheck, err := constraints.checkColumnVector(contradictionCheckVector)
@@ -125,7 +154,7 @@ func (constraints *KnownConstraints) InsertSumGroup(equation *SumGroup) (isValid
 		return false, err
 	}
 
-	transformedVector, err := constraints.equationTransformation.Muli64(columnVector)
+	transformedVector, err := constraints.equationTransformation.Mul(columnVector)
 
 	if err != nil {
 		return false, err
@@ -138,7 +167,7 @@ func (constraints *KnownConstraints) InsertSumGroup(equation *SumGroup) (isValid
 	blankIndex := UNUSED
 
 	for index := uint32(1); index < transformedVector.Rows; index = index + 1 {
-		entryValue := transformedVector.GetEntryi64(index, 0).Numerator
+		entryValue := transformedVector.GetEntry(index, 0).Sign()
 		if blankIndex == UNUSED && constraints.equationColumns[index-1].sumGroup == nil && entryValue != 0 {
 			blankIndex = index
 		}
@@ -152,16 +181,32 @@ func (constraints *KnownConstraints) InsertSumGroup(equation *SumGroup) (isValid
 		}
 	}
 
+	var constantValue = transformedVector.GetEntry(0, 0).Sign()
+
 	if blankIndex != UNUSED {
 		constraints.equationColumns[blankIndex-1] = equationColumnInfo{equation, false}
 		constraints.rowReduceVector(transformedVector, blankIndex)
 		return true, nil
-	} else if negativeCount == 0 {
+	} else if negativeCount == 0 && constantValue >= 0 {
 		return true, nil
-	} else if negativeCount == 1 && positiveCount == 0 {
+	} else if negativeCount == 1 && positiveCount == 0 && constantValue == 0 {
 		constraints.equationColumns[negativeIndex-1].isZero = true
+		var zeroSumGroup = constraints.equationColumns[negativeIndex-1].sumGroup
+
+		if zeroSumGroup != nil && constraints.boundingRules.Contains(zeroSumGroup) {
+			constraints.boundingRules.AddEquality(zeroSumGroup, nil, nil)
+		}
 		return true, nil
 	} else if positiveCount == 1 {
+		var replaced = constraints.equationColumns[positiveIndex-1].sumGroup
+
+		// rules written with the replaced column need to know how it relates
+		// to the new one since checks won't mention it anymore
+		if replaced != nil && constraints.boundingRules.Contains(replaced) {
+			sumGroups, values := constraints.extractVolumeValues(transformedVector)
+			constraints.boundingRules.AddEquality(equation, sumGroups, values)
+		}
+
 		constraints.equationColumns[positiveIndex-1] = equationColumnInfo{equation, false}
 		constraints.rowReduceVector(transformedVector, positiveIndex)
 		return true, nil
@@ -170,13 +215,19 @@ func (constraints *KnownConstraints) InsertSumGroup(equation *SumGroup) (isValid
 	}
 }
 
-func (constraints *KnownConstraints) extractVolumeValues(columnVector *zmath.Matrixi64) (sumGroups []*SumGroup, values []zmath.RationalNumberi64) {
+func (constraints *KnownConstraints) extractVolumeValues(columnVector *zmath.MatrixRat) (sumGroups []*SumGroup, values []*big.Rat) {
 	sumGroups = nil
 	values = nil
 
 	for row := uint32(0); row < columnVector.Rows; row = row + 1 {
-		var vectorValue = columnVector.GetEntryi64(0, row)
-		if !vectorValue.IsZero() {
+		var vectorValue = columnVector.GetEntry(row, 0)
+
+		// columns known to be zero add nothing no matter their scalar
+		if row != 0 && constraints.equationColumns[row-1].isZero {
+			continue
+		}
+
+		if vectorValue.Sign() != 0 {
 			if row == 0 {
 				sumGroups = append(sumGroups, nil)
 			} else {
@@ -189,50 +240,53 @@ func (constraints *KnownConstraints) extractVolumeValues(columnVector *zmath.Mat
 	return sumGroups, values
 }
 
-func (constraints *KnownConstraints) insertSumGroupIntoNDimension(equation *SumGroup, columnVector *zmath.Matrixi64) (isValid bool, err error) {
+func (constraints *KnownConstraints) insertSumGroupIntoNDimension(equation *SumGroup, columnVector *zmath.MatrixRat) (isValid bool, err error) {
 	sumGroups, values := constraints.extractVolumeValues(columnVector)
 
 	// TODO possibly pick replacement equation instead of always defaulting to new
 
-	err = constraints.sumSpaceBoundingVolume.Extrude(sumGroups, values)
-
-	if err != nil {
-		return false, err
-	}
+	constraints.boundingRules.Add(sumGroups, values)
 
 	return true, nil
 }
 
-func (constraints *KnownConstraints) rowReduceVector(vector *zmath.Matrixi64, pivotIndex uint32) {
-	pivotValue := vector.GetEntryi64(pivotIndex, 0)
+func (constraints *KnownConstraints) rowReduceVector(vector *zmath.MatrixRat, pivotIndex uint32) {
+	pivotValue := vector.GetEntry(pivotIndex, 0)
 
 	for index := uint32(0); index < vector.Rows; index = index + 1 {
 		if pivotIndex != index {
-			scalarValue := zmath.DivRi64(
-				vector.GetEntryi64(index, 0),
-				zmath.Muli64(pivotValue, -1),
+			scalarValue := new(big.Rat).Quo(
+				vector.GetEntry(index, 0),
+				new(big.Rat).Neg(pivotValue),
 			)
 
 			constraints.equationTransformation.AddRowToRow(pivotIndex, index, scalarValue)
 		}
 	}
 
-	constraints.equationTransformation.ScaleRowi64(pivotIndex, zmath.InvRi64(pivotValue))
+	constraints.equationTransformation.ScaleRow(pivotIndex, new(big.Rat).Inv(pivotValue))
 }
 
-func (constraints *KnownConstraints) extractColumnVector(equation *SumGroup) *zmath.Matrixi64 {
+// Returns nil if a scalar overflowed since the equation can't be written
+func (constraints *KnownConstraints) extractColumnVector(equation *SumGroup) *zmath.MatrixRat {
+	for _, productGroup := range equation.ProductGroups {
+		if productGroup.ConstantScalar.IsNaN() {
+			return nil
+		}
+	}
+
 	for _, productGroup := range equation.ProductGroups {
 		constraints.ensureProductGroup(productGroup.Values)
 	}
 
-	var result = zmath.NewMatrixi64(constraints.equationTransformation.Cols, 1)
-	result.InitialzeIdentityi64()
+	var result = zmath.NewMatrixRat(constraints.equationTransformation.Cols, 1)
 
-	result.SetEntryi64(0, 0, zmath.Ri64Fromi64(equation.ConstantOffset))
+	result.SetEntry(0, 0, big.NewRat(equation.ConstantOffset, 1))
 
 	for _, productGroup := range equation.ProductGroups {
 		var index = constraints.productGroupRows[productGroup.Values.uniqueID]
-		result.SetEntryi64(index.index, 0, productGroup.ConstantScalar)
+		var scalar = productGroup.ConstantScalar
+		result.SetEntry(index.index, 0, big.NewRat(scalar.Numerator, scalar.Denominator))
 	}
 
 	return result
@@ -268,7 +322,7 @@ func (from *KnownConstraints) Copy() *KnownConstraints {
 		equationColumns,
 		productGroupRows,
 		from.equationTransformation.Copy(),
-		from.sumSpaceBoundingVolume.Copy(),
+		from.boundingRules.Copy(),
 	}
 }
 
@@ -1,6 +1,7 @@
 package boundschecking
 
 import (
+	"math/big"
 	"testing"
 	"zen/test"
 )
@@ -71,3 +72,103 @@ func TestContradictions(t *testing.T) {
 	test.Assert(t, !insertResult, "The contradiction insert should fail")
 	test.Assert(t, err == nil, "The contradiction insert should not have failed with an error")
 }
+
+func TestUnknownColumns(t *testing.T) {
+	var constraints = NewKnownConstraints()
+	var nodeState = NewNormalizerState()
+
+	nodeState.UseIdentifierMapping("a", 1)
+
+	assertTrue(t, nodeState, constraints, "a - 1", false, "Nothing should be known about a new value")
+
+	insertResult, err := constraints.InsertSumGroup(nodeState.stringToSumGroup(t, "-a")) // a <= 0
+	test.Assert(t, insertResult && err == nil, "An upper bound on a new value should not be a contradiction")
+
+	insertResult, err = constraints.InsertSumGroup(nodeState.stringToSumGroup(t, "a")) // a >= 0
+	test.Assert(t, insertResult && err == nil, "A value can be equal to zero")
+}
+
+func TestRuleContradictions(t *testing.T) {
+	var nodeState = NewNormalizerState()
+
+	nodeState.UseIdentifierMapping("a", 1)
+	nodeState.UseIdentifierMapping("b", 2)
+
+	var a = nodeState.stringToSumGroup(t, "a")
+	var b = nodeState.stringToSumGroup(t, "b")
+	var rules = BoundingRules{}
+
+	// a > b
+	rules.Add([]*SumGroup{a, b, nil}, []*big.Rat{big.NewRat(1, 1), big.NewRat(-1, 1), big.NewRat(-1, 1)})
+	test.Assert(t, !rules.HasContradiction(), "A single rule should not be a contradiction")
+
+	// b >= a
+	rules.Add([]*SumGroup{b, a}, []*big.Rat{big.NewRat(1, 1), big.NewRat(-1, 1)})
+	test.Assert(t, rules.HasContradiction(), "Opposite rules should be a contradiction")
+}
+
+func TestRangeChecks(t *testing.T) {
+	var constraints = NewKnownConstraints()
+	var nodeState = NewNormalizerState()
+
+	nodeState.UseIdentifierMapping("a", 1)
+	nodeState.UseIdentifierMapping("b", 2)
+	nodeState.UseIdentifierMapping("c", 3)
+
+	constraints.InsertSumGroup(nodeState.stringToSumGroup(t, "a"))      // a >= 0
+	constraints.InsertSumGroup(nodeState.stringToSumGroup(t, "10 - a")) // a <= 10
+
+	assertTrue(t, nodeState, constraints, "10 - a", true, "Upper bound")
+	assertTrue(t, nodeState, constraints, "9 - a", false, "Upper bound is not tighter")
+	assertTrue(t, nodeState, constraints, "-a", false, "Upper bound should not force a to 0")
+
+	constraints.InsertSumGroup(nodeState.stringToSumGroup(t, "b"))
+	constraints.InsertSumGroup(nodeState.stringToSumGroup(t, "10 - b"))
+
+	assertTrue(t, nodeState, constraints, "20 - a - b", true, "a + b <= 20")
+	assertTrue(t, nodeState, constraints, "19 - a - b", false, "a + b <= 19")
+	assertTrue(t, nodeState, constraints, "10 - a + b", true, "a - b <= 10")
+
+	constraints.InsertSumGroup(nodeState.stringToSumGroup(t, "c - a - b"))
+	constraints.InsertSumGroup(nodeState.stringToSumGroup(t, "a + b - c"))
+
+	assertTrue(t, nodeState, constraints, "20 - c", true, "c = a + b <= 20")
+	assertTrue(t, nodeState, constraints, "19 - c", false, "c = a + b <= 19")
+
+	insertResult, _ := constraints.InsertSumGroup(nodeState.stringToSumGroup(t, "c - 21"))
+	test.Assert(t, !insertResult, "c >= 21 contradicts c <= 20")
+
+	nodeState.UseIdentifierMapping("d", 4)
+
+	constraints.InsertSumGroup(nodeState.stringToSumGroup(t, "d + 1000"))
+	constraints.InsertSumGroup(nodeState.stringToSumGroup(t, "1000 - d"))
+	constraints.InsertSumGroup(nodeState.stringToSumGroup(t, "5 - d"))
+	constraints.InsertSumGroup(nodeState.stringToSumGroup(t, "d - 5"))
+
+	assertTrue(t, nodeState, constraints, "255 - d", true, "d = 5 after its range is known")
+	assertTrue(t, nodeState, constraints, "4 - d", false, "d = 5 is not below 5")
+
+	nodeState.UseIdentifierMapping("e", 5)
+
+	constraints.InsertSumGroup(nodeState.stringToSumGroup(t, "e + 1000"))
+	constraints.InsertSumGroup(nodeState.stringToSumGroup(t, "1000 - e"))
+	constraints.InsertSumGroup(nodeState.stringToSumGroup(t, "a - e"))
+	constraints.InsertSumGroup(nodeState.stringToSumGroup(t, "e - a"))
+
+	assertTrue(t, nodeState, constraints, "e", true, "e = a >= 0")
+	assertTrue(t, nodeState, constraints, "10 - e", true, "e = a <= 10")
+}
+
+func TestWideRanges(t *testing.T) {
+	var constraints = NewKnownConstraints()
+	var nodeState = NewNormalizerState()
+
+	nodeState.UseIdentifierMapping("a", 1)
+
+	constraints.InsertSumGroup(nodeState.stringToSumGroup(t, "a + 9223372036854775807"))
+	constraints.InsertSumGroup(nodeState.stringToSumGroup(t, "9223372036854775807 - a"))
+
+	assertTrue(t, nodeState, constraints, "9223372036854775807 - a", true, "Upper bound")
+	assertTrue(t, nodeState, constraints, "a - 5", false, "Checks that overflow should not hold")
+	assertTrue(t, nodeState, constraints, "a", false, "a can be negative")
+}
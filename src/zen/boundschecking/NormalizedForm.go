@@ -244,10 +244,10 @@ func (sumGroup *SumGroup) Compare(other NormalizedNode) int {
 			return len(sumGroup.ProductGroups) - len(otherAsSumGroup.ProductGroups)
 		}
 
-		scalarCompare := sumGroup.ConstantOffset - otherAsSumGroup.ConstantOffset
-
-		if scalarCompare != 0 {
-			return int(scalarCompare)
+		if sumGroup.ConstantOffset < otherAsSumGroup.ConstantOffset {
+			return -1
+		} else if sumGroup.ConstantOffset > otherAsSumGroup.ConstantOffset {
+			return 1
 		}
 
 		for index, node := range sumGroup.ProductGroups {
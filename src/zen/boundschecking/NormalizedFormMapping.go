@@ -1,6 +1,9 @@
 package boundschecking
 
-import "zen/parser"
+import (
+	"zen/parser"
+	"zen/zmath"
+)
 
 func (orGroup *OrGroup) MapNodes(
 	state *NormalizerState,
@@ -71,3 +74,128 @@ func (variableReference *VariableReference) MapNodes(state *NormalizerState, map
 func (propertyReference *PropertyReference) MapNodes(state *NormalizerState, mapping map[int]int) *PropertyReference {
 	return state.CreatePropertyReference(propertyReference.Left, propertyReference.Right, mapping[propertyReference.valueId])
 }
+
+// Replaces nodes with the sum groups they map to. This is used to move the
+// contract of a function into the frame of whoever calls it
+func (orGroup *OrGroup) SubstituteNodes(
+	state *NormalizerState,
+	substitutions map[NormalizedNode]*SumGroup,
+	exprMapping map[uint32]parser.Expression,
+	intoExprMapping map[uint32]parser.Expression,
+) (*OrGroup, error) {
+	var result []*AndGroup = nil
+
+	for _, andGroup := range orGroup.AndGroups {
+		substituted, err := andGroup.SubstituteNodes(state, substitutions, exprMapping, intoExprMapping)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if substituted != nil {
+			result = append(result, substituted)
+		}
+	}
+
+	return state.CreateOrGroup(result), nil
+}
+
+func (andGroup *AndGroup) SubstituteNodes(
+	state *NormalizerState,
+	substitutions map[NormalizedNode]*SumGroup,
+	exprMapping map[uint32]parser.Expression,
+	intoExprMapping map[uint32]parser.Expression,
+) (*AndGroup, error) {
+	var result []*SumGroup = make([]*SumGroup, len(andGroup.SumGroups))
+
+	for index, sumGroup := range andGroup.SumGroups {
+		substituted, err := sumGroup.SubstituteNodes(state, substitutions)
+
+		if err != nil {
+			return nil, err
+		}
+
+		expression, ok := exprMapping[sumGroup.uniqueId]
+
+		if ok {
+			intoExprMapping[substituted.uniqueId] = expression
+		}
+
+		result[index] = substituted
+	}
+
+	return state.CreateAndGroup(result), nil
+}
+
+func (sumGroup *SumGroup) SubstituteNodes(state *NormalizerState, substitutions map[NormalizedNode]*SumGroup) (*SumGroup, error) {
+	var result *SumGroup = nil
+
+	for _, productGroup := range sumGroup.ProductGroups {
+		var product *SumGroup = nil
+
+		for _, node := range productGroup.Values.Array {
+			var substituted = substituteNode(state, substitutions, node)
+
+			if product == nil {
+				product = substituted
+			} else {
+				product = state.multiplySumGroups(product, substituted)
+			}
+		}
+
+		if product != nil {
+			scaled, err := state.scaleSumGroup(product, productGroup.ConstantScalar)
+
+			if err != nil {
+				return nil, err
+			}
+
+			result = state.addSumGroups(result, scaled, 0)
+		}
+	}
+
+	if result == nil {
+		return state.CreateSumGroup(nil, sumGroup.ConstantOffset), nil
+	}
+
+	offset, ok := zmath.AddCheckedi64(result.ConstantOffset, sumGroup.ConstantOffset)
+
+	return state.createSumGroupWithOffset(result.ProductGroups, offset, ok), nil
+}
+
+func singleNode(sumGroup *SumGroup) NormalizedNode {
+	if sumGroup.ConstantOffset != 0 ||
+		len(sumGroup.ProductGroups) != 1 ||
+		len(sumGroup.ProductGroups[0].Values.Array) != 1 ||
+		!sumGroup.ProductGroups[0].ConstantScalar.IsOne() {
+		return nil
+	}
+
+	return sumGroup.ProductGroups[0].Values.Array[0]
+}
+
+func substituteNode(state *NormalizerState, substitutions map[NormalizedNode]*SumGroup, node NormalizedNode) *SumGroup {
+	replacement, ok := substitutions[node]
+
+	if ok {
+		return replacement
+	}
+
+	asProperty, ok := node.(*PropertyReference)
+
+	if ok {
+		var left = singleNode(substituteNode(state, substitutions, asProperty.Left))
+
+		if left == nil {
+			// a property of a computed value has no name in the new frame
+			// so it is given a fresh variable instead
+			replacement = state.SumGroupFromNode(state.CreateVariableReference(asProperty.Right, parser.NextUniqueId()))
+			substitutions[node] = replacement
+			return replacement
+		} else if left != asProperty.Left {
+			return state.SumGroupFromNode(state.CreatePropertyReference(left, asProperty.Right, asProperty.valueId))
+		}
+	}
+
+	return state.SumGroupFromNode(node)
+}
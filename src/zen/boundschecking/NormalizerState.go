@@ -1,13 +1,21 @@
 package boundschecking
 
 import (
+	"errors"
+	"math"
 	"sort"
+	"strconv"
 	"zen/parser"
 	"zen/zmath"
 )
 
 type IdentifierSource struct {
-	UniqueId int
+	UniqueId    int
+	Declaration int
+}
+
+type IdentifierMapping struct {
+	identifiers map[string]IdentifierSource
 }
 
 type NormalizerState struct {
@@ -15,6 +23,9 @@ type NormalizerState struct {
 	currentUniqueID          uint32
 	identfierSourceMapping   map[string]IdentifierSource
 	currentExpressionMapping map[uint32]parser.Expression
+	expressionNodes          map[parser.Expression]NormalizedNode
+	oldMapping               *IdentifierMapping
+	postMapping              *IdentifierMapping
 }
 
 func NewNormalizerState() *NormalizerState {
@@ -23,6 +34,9 @@ func NewNormalizerState() *NormalizerState {
 		uint32(0),
 		make(map[string]IdentifierSource),
 		nil,
+		make(map[parser.Expression]NormalizedNode),
+		nil,
+		nil,
 	}
 }
 
@@ -42,8 +56,115 @@ func (state *NormalizerState) StopTrackingExpressionMapping() {
 	state.currentExpressionMapping = nil
 }
 
+// Expressions that cannot be normalized directly, such as function calls,
+// can be given a node that stands in for their value
+func (state *NormalizerState) UseExpressionNode(expression parser.Expression, node NormalizedNode) {
+	state.expressionNodes[expression] = node
+}
+
 func (state *NormalizerState) UseIdentifierMapping(name string, id int) {
-	state.identfierSourceMapping[name] = IdentifierSource{id}
+	state.identfierSourceMapping[name] = IdentifierSource{id, id}
+}
+
+// Gives an identifier a new value id so facts about its previous value no
+// longer apply to it
+func (state *NormalizerState) ReassignIdentifier(name string) *VariableReference {
+	var source, ok = state.identfierSourceMapping[name]
+
+	if !ok {
+		source.Declaration = parser.NextUniqueId()
+	}
+
+	source.UniqueId = parser.NextUniqueId()
+	state.identfierSourceMapping[name] = source
+
+	return state.CreateVariableReference(name, source.UniqueId)
+}
+
+func (state *NormalizerState) GetIdentifierSource(name string) (IdentifierSource, bool) {
+	source, ok := state.identfierSourceMapping[name]
+	return source, ok
+}
+
+func (state *NormalizerState) GetIdentifierMapping() IdentifierMapping {
+	var identifiers = make(map[string]IdentifierSource)
+
+	for name, source := range state.identfierSourceMapping {
+		identifiers[name] = source
+	}
+
+	return IdentifierMapping{identifiers}
+}
+
+func (mapping IdentifierMapping) Source(name string) (IdentifierSource, bool) {
+	source, ok := mapping.identifiers[name]
+	return source, ok
+}
+
+func (mapping IdentifierMapping) NamesForDeclarations(declarations map[int]bool) []string {
+	var result []string = nil
+
+	for name, source := range mapping.identifiers {
+		if declarations[source.Declaration] {
+			result = append(result, name)
+		}
+	}
+
+	return result
+}
+
+// While normalizing a contract old(...) refers to the values a function was
+// called with and post clauses refer to the values it returns with
+func (state *NormalizerState) UseContractMappings(old IdentifierMapping, post IdentifierMapping) {
+	state.oldMapping = &old
+	state.postMapping = &post
+}
+
+func (state *NormalizerState) StopContractMappings() {
+	state.oldMapping = nil
+	state.postMapping = nil
+}
+
+// Switches identifiers to mapping and returns the mapping to switch back to
+func (state *NormalizerState) useMapping(mapping *IdentifierMapping) map[string]IdentifierSource {
+	var previous = state.identfierSourceMapping
+
+	if mapping != nil {
+		state.identfierSourceMapping = mapping.identifiers
+	}
+
+	return previous
+}
+
+func (state *NormalizerState) RestoreIdentifierMapping(mapping IdentifierMapping) {
+	state.identfierSourceMapping = make(map[string]IdentifierSource)
+
+	for name, source := range mapping.identifiers {
+		state.identfierSourceMapping[name] = source
+	}
+}
+
+// Goes back to mapping for names declared since it was taken but keeps the
+// values its own names were reassigned to. Returns the names that were
+// declared again and went back to their value from mapping
+func (state *NormalizerState) RestoreDeclarations(mapping IdentifierMapping) []string {
+	var result = make(map[string]IdentifierSource)
+	var shadowed []string = nil
+
+	for name, source := range mapping.identifiers {
+		current, ok := state.identfierSourceMapping[name]
+
+		if ok && current.Declaration == source.Declaration {
+			result[name] = current
+		} else {
+			result[name] = source
+			shadowed = append(shadowed, name)
+		}
+	}
+
+	state.identfierSourceMapping = result
+
+	return shadowed
 }
 
 func (state *NormalizerState) getNextUniqueId() uint32 {
@@ -174,8 +295,6 @@ func (state *NormalizerState) addProductGroups(a []*ProductGroup, b []*ProductGr
 			bIndex = bIndex + 1
 		} else {
 			scalarResult := zmath.AddRi64(a[aIndex].ConstantScalar, b[bIndex].ConstantScalar)
-			aIndex = aIndex + 1
-			bIndex = bIndex + 1
 
 			if !scalarResult.IsZero() {
 				result = append(result, state.nodeCache.GetNodeSingleton(&ProductGroup{
@@ -183,12 +302,44 @@ func (state *NormalizerState) addProductGroups(a []*ProductGroup, b []*ProductGr
 					scalarResult,
 				}).(*ProductGroup))
 			}
+
+			aIndex = aIndex + 1
+			bIndex = bIndex + 1
 		}
 	}
 
 	return result
 }
 
+// Scalars created by normalization are integers so the offset stays exact
+// Sum groups only hold whole offsets so scaling by a fraction that doesn't
+// divide the offset is an error rather than a rounded value
+func (state *NormalizerState) scaleSumGroup(a *SumGroup, scalar zmath.RationalNumberi64) (result *SumGroup, err error) {
+	if scalar.IsOne() {
+		return a, nil
+	}
+
+	var values []*ProductGroup = nil
+
+	for _, group := range a.ProductGroups {
+		var scaled = state.multiplyProductGroupByScalar(group, scalar)
+
+		if scaled != nil {
+			values = append(values, scaled)
+		}
+	}
+
+	var offset = zmath.Muli64(scalar, a.ConstantOffset).SimplifyRi64()
+
+	if offset.IsNaN() {
+		return state.createSumGroupWithOffset(values, 0, false), nil
+	} else if offset.Denominator != 1 {
+		return nil, errors.New("Scaling the offset " + strconv.FormatInt(a.ConstantOffset, 10) + " does not give a whole number")
+	}
+
+	return state.createSumGroupWithOffset(values, offset.Numerator, true), nil
+}
+
 func (state *NormalizerState) negateSumGroup(a *SumGroup) *SumGroup {
 	var values []*ProductGroup = nil
 
@@ -253,13 +404,10 @@ func (state *NormalizerState) addSumGroups(a *SumGroup, b *SumGroup, extraOffset
 		bIndex = bIndex + 1
 	}
 
-	var result = &SumGroup{
-		values,
-		a.ConstantOffset + b.ConstantOffset + extraOffset,
-		state.getNextUniqueId(),
-	}
+	offset, offsetOk := zmath.AddCheckedi64(a.ConstantOffset, b.ConstantOffset)
+	offset, extraOk := zmath.AddCheckedi64(offset, extraOffset)
 
-	return state.nodeCache.GetNodeSingleton(result).(*SumGroup)
+	return state.createSumGroupWithOffset(values, offset, offsetOk && extraOk)
 }
 
 func (state *NormalizerState) multiplySumGroups(a *SumGroup, b *SumGroup) *SumGroup {
@@ -285,11 +433,9 @@ func (state *NormalizerState) multiplySumGroups(a *SumGroup, b *SumGroup) *SumGr
 		values = state.addProductGroups(values, scaledA)
 	}
 
-	return state.nodeCache.GetNodeSingleton(&SumGroup{
-		values,
-		a.ConstantOffset * b.ConstantOffset,
-		state.getNextUniqueId(),
-	}).(*SumGroup)
+	offset, ok := zmath.MulCheckedi64(a.ConstantOffset, b.ConstantOffset)
+
+	return state.createSumGroupWithOffset(values, offset, ok)
 }
 
 func ZipSumGroups(a []*SumGroup, b []*SumGroup) []*SumGroup {
@@ -394,7 +540,11 @@ func (state *NormalizerState) combineOrGroupsWithAnd(a *OrGroup, b *OrGroup) *Or
 	var result *OrGroup = nil
 
 	for _, subGroup := range a.AndGroups {
-		result = state.combineOrWithAndGroup(b, subGroup)
+		result = state.combineOrGroups(result, state.combineOrWithAndGroup(b, subGroup))
+	}
+
+	if result == nil {
+		return &OrGroup{nil}
 	}
 
 	return state.nodeCache.GetNodeSingleton(result).(*OrGroup)
@@ -403,11 +553,9 @@ func (state *NormalizerState) combineOrGroupsWithAnd(a *OrGroup, b *OrGroup) *Or
 func (state *NormalizerState) notSumGroup(sumGroup *SumGroup) *SumGroup {
 	var result = state.negateSumGroup(sumGroup)
 
-	return state.nodeCache.GetNodeSingleton(&SumGroup{
-		result.ProductGroups,
-		result.ConstantOffset - 1,
-		state.getNextUniqueId(),
-	}).(*SumGroup)
+	offset, ok := zmath.AddCheckedi64(result.ConstantOffset, -1)
+
+	return state.createSumGroupWithOffset(result.ProductGroups, offset, ok)
 }
 
 func (state *NormalizerState) notAndGroup(andGroup *AndGroup) *OrGroup {
@@ -437,17 +585,90 @@ func (state *NormalizerState) NotOrGroup(orGroup *OrGroup) *OrGroup {
 		result = state.combineOrGroups(result, state.notAndGroup(andGroup))
 	}
 
+	if result == nil {
+		return &OrGroup{nil}
+	}
+
 	return result
 }
 
 func (state *NormalizerState) CreateEquality(sumGroups *SumGroup, id NormalizedNode) []*SumGroup {
-	var halfGroup = state.addSumGroups(sumGroups, state.negateSumGroup(state.sumGroupFromNode(id)), int64(0))
+	var halfGroup = state.addSumGroups(sumGroups, state.negateSumGroup(state.SumGroupFromNode(id)), int64(0))
 	return []*SumGroup{
 		halfGroup,
 		state.negateSumGroup(halfGroup),
 	}
 }
 
+// The rule for value >= min
+func (state *NormalizerState) CreateLowerBound(value *SumGroup, min int64) *SumGroup {
+	return state.addSumGroups(value, state.CreateSumGroup(nil, -min), 0)
+}
+
+// The rule for value <= max
+func (state *NormalizerState) CreateUpperBound(value *SumGroup, max int64) *SumGroup {
+	return state.addSumGroups(state.negateSumGroup(value), state.CreateSumGroup(nil, max), 0)
+}
+
+// The rules to check that a value is between min and max inclusive. A bound
+// too far from the offset of the value to fit in 64 bits is moved to the
+// furthest offset that fits, which only makes the check stricter
+func (state *NormalizerState) CreateRangeCheck(value *SumGroup, min int64, max int64) []*SumGroup {
+	lowerOffset, lowerOk := zmath.AddCheckedi64(value.ConstantOffset, -min)
+	upperOffset, upperOk := zmath.AddCheckedi64(-value.ConstantOffset, max)
+
+	if !lowerOk {
+		lowerOffset = math.MaxInt64
+	}
+
+	if !upperOk {
+		upperOffset = math.MaxInt64
+	}
+
+	return []*SumGroup{
+		state.CreateSumGroup(value.ProductGroups, lowerOffset),
+		state.CreateSumGroup(state.negateSumGroup(value).ProductGroups, upperOffset),
+	}
+}
+
+// The rule for a < b
+func (state *NormalizerState) CreateLessThan(a *SumGroup, b *SumGroup) *SumGroup {
+	return state.addSumGroups(b, state.negateSumGroup(a), -1)
+}
+
+// Booleans are 1 when true and 0 when false. The rules for node holding the
+// value of condition, nil if nothing is known about the condition
+func (state *NormalizerState) CreateBooleanLink(node NormalizedNode, condition *OrGroup) *OrGroup {
+	if condition == nil || len(condition.AndGroups) == 0 {
+		return nil
+	}
+
+	var value = state.SumGroupFromNode(node)
+	var isTrue = state.CreateOrGroup([]*AndGroup{state.CreateAndGroup([]*SumGroup{state.CreateLowerBound(value, 1)})})
+	var isFalse = state.CreateOrGroup([]*AndGroup{state.CreateAndGroup([]*SumGroup{state.CreateUpperBound(value, 0)})})
+
+	return state.combineOrGroups(
+		state.combineOrGroupsWithAnd(isTrue, condition),
+		state.combineOrGroupsWithAnd(isFalse, state.NotOrGroup(condition)),
+	)
+}
+
+// The rules for a value between min and max inclusive
+func (state *NormalizerState) CreateRange(value *SumGroup, min int64, max int64) []*SumGroup {
+	return []*SumGroup{
+		state.CreateLowerBound(value, min),
+		state.CreateUpperBound(value, max),
+	}
+}
+
+// The rules an index must follow to be inside of an array, 0 <= index and
+// index < length
+func (state *NormalizerState) CreateIndexBounds(index *SumGroup, length NormalizedNode) *AndGroup {
+	var upperBound = state.addSumGroups(state.SumGroupFromNode(length), state.negateSumGroup(index), int64(-1))
+
+	return state.CreateAndGroup([]*SumGroup{index, upperBound})
+}
+
 func (state *NormalizerState) CreateNormalizedNodeArray(nodes []NormalizedNode) *NormalizedNodeArray {
 	return state.nodeCache.GetNodeSingleton(&NormalizedNodeArray{
 		nodes,
@@ -462,6 +683,18 @@ func (state *NormalizerState) CreateProductGroup(nodes []NormalizedNode, constan
 	}).(*ProductGroup)
 }
 
+// An offset that doesn't fit in 64 bits can't be written exactly so an
+// unknown value is added in its place. Nothing can be proven about the sum
+// and assuming it doesn't restrict any other value
+func (state *NormalizerState) createSumGroupWithOffset(productGroups []*ProductGroup, offset int64, ok bool) *SumGroup {
+	if ok {
+		return state.CreateSumGroup(productGroups, offset)
+	}
+
+	var unknown = state.CreateVariableReference("overflow", parser.NextUniqueId())
+	return state.addSumGroups(state.CreateSumGroup(productGroups, 0), state.SumGroupFromNode(unknown), 0)
+}
+
 func (state *NormalizerState) CreateSumGroup(productGroups []*ProductGroup, constantOffset int64) *SumGroup {
 	return state.nodeCache.GetNodeSingleton(&SumGroup{
 		productGroups,
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"zen/parser"
 	"zen/test"
+	"zen/zmath"
 )
 
 func (nodeState *NormalizerState) stringToSumGroup(t *testing.T, source string) *SumGroup {
@@ -125,6 +126,19 @@ func TestRemoveZeros(t *testing.T) {
 	test.Assert(t, nodeState.stringToSumGroup(t, "a*0 - b*a") == nodeState.stringToSumGroup(t, "-a*b"), "a*0 - b*a")
 }
 
+func TestScaleSumGroup(t *testing.T) {
+	var nodeState = NewNormalizerState()
+	var half = zmath.InvRi64(zmath.Ri64Fromi64(2))
+
+	nodeState.UseIdentifierMapping("a", 1)
+
+	scaled, err := nodeState.scaleSumGroup(nodeState.stringToSumGroup(t, "a*2 + 4"), half)
+	test.Assert(t, err == nil && scaled == nodeState.stringToSumGroup(t, "a + 2"), "a*2 + 4 scaled by 1/2")
+
+	_, err = nodeState.scaleSumGroup(nodeState.stringToSumGroup(t, "a*2 + 3"), half)
+	test.Assert(t, err != nil, "Offsets that don't divide should not be rounded")
+}
+
 func TestOrGroups(t *testing.T) {
 	var nodeState = NewNormalizerState()
 
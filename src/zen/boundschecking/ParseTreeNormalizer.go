@@ -2,6 +2,7 @@ package boundschecking
 
 import (
 	"errors"
+	"math"
 	"strconv"
 	"zen/parser"
 	"zen/tokenizer"
@@ -111,9 +112,50 @@ func (state *NormalizerState) NormalizeToOrGroup(expression parser.Expression) *
 		return state.normalizeBinaryExpressionToOrGroup(asBinaryExpression)
 	}
 
+	asUnaryExpression, ok := expression.(*parser.UnaryExpression)
+
+	if ok && isPostMarker(asUnaryExpression) {
+		var previous = state.useMapping(state.postMapping)
+		var result = state.NormalizeToOrGroup(asUnaryExpression.Expr)
+		state.identfierSourceMapping = previous
+		return result
+	} else if ok && asUnaryExpression.Operator.TokenType == tokenizer.NotToken {
+		return state.NotOrGroup(state.NormalizeToOrGroup(asUnaryExpression.Expr))
+	}
+
+	// variables and fields holding a boolean are true when they are 1
+	if expression.GetType() != nil && expression.GetType().GetNodeType() == parser.BooleanNodeType {
+		node, err := state.NormalizeToNode(expression)
+
+		if err == nil {
+			var isTrue = state.recordExpressionMapping(state.CreateLowerBound(state.SumGroupFromNode(node), 1), expression)
+			return state.CreateOrGroup([]*AndGroup{state.CreateAndGroup([]*SumGroup{isTrue})})
+		}
+	}
+
 	return &OrGroup{nil}
 }
 
+func isPostMarker(expression *parser.UnaryExpression) bool {
+	return expression.Operator.TokenType == tokenizer.IDToken && expression.Operator.Value == "post"
+}
+
+func isOldCall(call *parser.CallExpression) bool {
+	asBuiltin, ok := call.Function.GetType().(*parser.BuiltinFunctionType)
+	return ok && asBuiltin.Name == "old" && len(call.Arguments) == 1
+}
+
+func (state *NormalizerState) normalizeOldToSumGroup(call *parser.CallExpression) (result *SumGroup, err error) {
+	if state.oldMapping == nil {
+		return nil, errors.New("old can only be used in a contract")
+	}
+
+	var previous = state.useMapping(state.oldMapping)
+	result, err = state.NormalizeToSumGroup(call.Arguments[0])
+	state.identfierSourceMapping = previous
+	return result, err
+}
+
 func (state *NormalizerState) normalizeUnaryExpressionToSumGroup(expression *parser.UnaryExpression) (result *SumGroup, err error) {
 	expr, err := state.NormalizeToSumGroup(expression.Expr)
 
@@ -147,15 +189,42 @@ func (state *NormalizerState) normalizeBinaryExpressionToSumGroup(expression *pa
 		return state.addSumGroups(left, state.negateSumGroup(right), 0), nil
 	} else if expression.Operator.TokenType == tokenizer.MultiplyToken {
 		return state.multiplySumGroups(left, right), nil
+	} else if isDivisionOperator(expression.Operator.TokenType) && len(left.ProductGroups) == 0 && len(right.ProductGroups) == 0 && right.ConstantOffset != 0 {
+		return state.foldDivision(expression.Operator.TokenType, left.ConstantOffset, right.ConstantOffset), nil
 	} else {
 		return nil, errors.New("Could not combine operator " + expression.Operator.Value)
 	}
 }
 
+func isDivisionOperator(tokenType tokenizer.TokenType) bool {
+	return tokenType == tokenizer.DivideToken || tokenType == tokenizer.ModToken
+}
+
+// Division of two known values is folded into a single offset so constants
+// like MaxPlayers / 2 stay linear. The only quotient that overflows is
+// MinInt64 / -1
+func (state *NormalizerState) foldDivision(operator tokenizer.TokenType, left int64, right int64) *SumGroup {
+	if left == math.MinInt64 && right == -1 {
+		return state.createSumGroupWithOffset(nil, 0, false)
+	} else if operator == tokenizer.DivideToken {
+		return state.CreateSumGroup(nil, left/right)
+	} else {
+		return state.CreateSumGroup(nil, left%right)
+	}
+}
+
 func (state *NormalizerState) NormalizeToNode(expression parser.Expression) (result NormalizedNode, err error) {
-	asIdentifier, ok := expression.(*parser.Identifier)
+	expressionNode, ok := state.expressionNodes[expression]
 
 	if ok {
+		return expressionNode, nil
+	}
+
+	asIdentifier, ok := expression.(*parser.Identifier)
+
+	if ok && asIdentifier.Constant != nil {
+		return nil, errors.New("Constant " + asIdentifier.Token.Value + " is not a value")
+	} else if ok {
 		return state.nodeCache.GetNodeSingleton(&VariableReference{
 			asIdentifier.Token.Value,
 			state.identfierSourceMapping[asIdentifier.Token.Value].UniqueId,
@@ -178,10 +247,62 @@ func (state *NormalizerState) NormalizeToNode(expression parser.Expression) (res
 		}), nil
 	}
 
+	asCall, ok := expression.(*parser.CallExpression)
+
+	if ok && isOldCall(asCall) {
+		if state.oldMapping == nil {
+			return nil, errors.New("old can only be used in a contract")
+		}
+
+		var previous = state.useMapping(state.oldMapping)
+		result, err = state.NormalizeToNode(asCall.Arguments[0])
+		state.identfierSourceMapping = previous
+		return result, err
+	} else if ok {
+		return state.normalizeBuiltinCall(asCall)
+	}
+
 	return nil, errors.New("Cannot convert to node")
 }
 
+// Len(array) is the same value as array.length
+func (state *NormalizerState) normalizeBuiltinCall(call *parser.CallExpression) (result NormalizedNode, err error) {
+	asBuiltin, ok := call.Function.GetType().(*parser.BuiltinFunctionType)
+
+	if !ok || asBuiltin.Name != "Len" || len(call.Arguments) != 1 {
+		return nil, errors.New("Cannot convert to node")
+	}
+
+	array, err := state.NormalizeToNode(call.Arguments[0])
+
+	if err != nil {
+		return nil, err
+	}
+
+	return state.CreatePropertyReference(array, "length", 0), nil
+}
+
+// Constants are folded into the offset of a sum group so proofs using them
+// stay linear
+func (state *NormalizerState) normalizeConstant(constDef *parser.ConstantDefinition) (result *SumGroup, err error) {
+	result, err = state.NormalizeToSumGroup(constDef.Value)
+
+	if err != nil {
+		return nil, err
+	} else if len(result.ProductGroups) != 0 {
+		return nil, errors.New("Constant " + constDef.Name.Value + " is not known at compile time")
+	}
+
+	return result, nil
+}
+
 func (state *NormalizerState) NormalizeToSumGroup(expression parser.Expression) (result *SumGroup, err error) {
+	expressionNode, ok := state.expressionNodes[expression]
+
+	if ok {
+		return state.SumGroupFromNode(expressionNode), nil
+	}
+
 	asBinaryExpression, ok := expression.(*parser.BinaryExpression)
 
 	if ok {
@@ -194,6 +315,18 @@ func (state *NormalizerState) NormalizeToSumGroup(expression parser.Expression)
 		return state.normalizeUnaryExpressionToSumGroup(asUnaryExpression)
 	}
 
+	asCall, ok := expression.(*parser.CallExpression)
+
+	if ok && isOldCall(asCall) {
+		return state.normalizeOldToSumGroup(asCall)
+	}
+
+	asIdentifier, ok := expression.(*parser.Identifier)
+
+	if ok && asIdentifier.Constant != nil {
+		return state.normalizeConstant(asIdentifier.Constant)
+	}
+
 	asNumber, ok := expression.(*parser.Number)
 
 	if ok {
@@ -216,10 +349,10 @@ func (state *NormalizerState) NormalizeToSumGroup(expression parser.Expression)
 		return nil, err
 	}
 
-	return state.sumGroupFromNode(asNode), nil
+	return state.SumGroupFromNode(asNode), nil
 }
 
-func (state *NormalizerState) sumGroupFromNode(node NormalizedNode) *SumGroup {
+func (state *NormalizerState) SumGroupFromNode(node NormalizedNode) *SumGroup {
 	var productGroup = state.nodeCache.GetNodeSingleton(&ProductGroup{
 		state.nodeCache.GetNodeSingleton(&NormalizedNodeArray{
 			[]NormalizedNode{node},
@@ -58,6 +58,7 @@ func variablesForStructure(structure *parser.StructureTypeType) []typeVariables
 }
 
 func typeConstraintsFromType(state *boundschecking.NormalizerState, typeNode parser.TypeNode) (TypeConstraints, error) {
+	var previousMapping = state.GetIdentifierMapping()
 	state.UseIdentifierMapping("self", typeNode.UniqueId())
 
 	asStruct, isStruct := typeNode.(*parser.StructureTypeType)
@@ -71,6 +72,7 @@ func typeConstraintsFromType(state *boundschecking.NormalizerState, typeNode par
 	var expressionMapping = state.StartTrackingExpressionMapping()
 	var constraints = state.NormalizeToOrGroup(typeNode.GetWhereExpression())
 	state.StopTrackingExpressionMapping()
+	state.RestoreIdentifierMapping(previousMapping)
 
 	contradictions, err := boundschecking.FindContraditions(constraints)
 
@@ -159,6 +161,13 @@ func (typeConstraintCache *TypeConstraintDifferCache) createTypeDiff(from parser
 		return TypeConstraintDiff{}, nil
 	}
 
+	fromFunction, isFromFunction := from.(*parser.FunctionTypeType)
+	toFunction, isToFunction := to.(*parser.FunctionTypeType)
+
+	if isFromFunction && isToFunction {
+		return typeConstraintCache.createFunctionTypeDiff(fromFunction, toFunction)
+	}
+
 	fromConstraints, err := typeConstraintCache.GetConstraintsForType(from)
 
 	if err != nil {
@@ -202,10 +211,107 @@ func (typeConstraintCache *TypeConstraintDifferCache) createTypeDiff(from parser
 	}, nil
 }
 
+// A function can be used as another function type if it accepts every input
+// the other type accepts and promises at least what the other type promises.
+// Inputs and outputs are matched by position and the diff holds the
+// conditions that couldn't be shown
+func (typeConstraintCache *TypeConstraintDifferCache) createFunctionTypeDiff(from *parser.FunctionTypeType, to *parser.FunctionTypeType) (TypeConstraintDiff, error) {
+	var normalizerState = typeConstraintCache.state
+	var fromContract = newFunctionContract(normalizerState, from)
+	var toContract = newFunctionContract(normalizerState, to)
+	var substitutions = make(map[boundschecking.NormalizedNode]*boundschecking.SumGroup)
+
+	for index, input := range fromContract.inputs {
+		if index < len(toContract.inputs) {
+			substitutions[input] = normalizerState.SumGroupFromNode(toContract.inputs[index])
+			substitutions[fromContract.postInputs[index]] = normalizerState.SumGroupFromNode(toContract.postInputs[index])
+		}
+	}
+
+	for index, output := range fromContract.outputs {
+		if index < len(toContract.outputs) {
+			substitutions[output] = normalizerState.SumGroupFromNode(toContract.outputs[index])
+		}
+	}
+
+	var exprMapping = make(map[uint32]parser.Expression)
+
+	for id, expression := range toContract.expressionMapping {
+		exprMapping[id] = expression
+	}
+
+	var fromConditions *boundschecking.OrGroup = nil
+
+	if fromContract.conditions != nil {
+		var err error
+		fromConditions, err = fromContract.conditions.SubstituteNodes(
+			normalizerState,
+			substitutions,
+			fromContract.expressionMapping,
+			exprMapping,
+		)
+
+		if err != nil {
+			return TypeConstraintDiff{}, err
+		}
+	}
+
+	var postVariables = toContract.postVariables()
+	var fromPreConditions = preConditionsOf(normalizerState, fromConditions, postVariables)
+	var toGroups = []*boundschecking.AndGroup{nil}
+
+	if toContract.conditions != nil && len(toContract.conditions.AndGroups) != 0 {
+		toGroups = toContract.conditions.AndGroups
+	}
+
+	var missing []*boundschecking.SumGroup = nil
+
+	for _, toGroup := range toGroups {
+		var pre []*boundschecking.SumGroup = nil
+		var post []*boundschecking.SumGroup = nil
+
+		if toGroup != nil {
+			for _, sumGroup := range toGroup.SumGroups {
+				if mentionsVariables(sumGroup, postVariables) {
+					post = append(post, sumGroup)
+				} else {
+					pre = append(pre, sumGroup)
+				}
+			}
+		}
+
+		var state = NewConstraintCheckerState()
+		state.addSumGroups(pre)
+
+		if fromPreConditions != nil {
+			result, _ := state.checkOrGroup(fromPreConditions)
+			missing = append(missing, result...)
+		}
+
+		if fromConditions != nil {
+			state.addRules(fromConditions.AndGroups)
+		}
+
+		if len(post) != 0 {
+			result, _ := state.checkAndGroup(normalizerState.CreateAndGroup(post))
+			missing = append(missing, result...)
+		}
+	}
+
+	if len(missing) == 0 {
+		return TypeConstraintDiff{nil, exprMapping}, nil
+	}
+
+	return TypeConstraintDiff{
+		normalizerState.CreateOrGroup([]*boundschecking.AndGroup{normalizerState.CreateAndGroup(missing)}),
+		exprMapping,
+	}, nil
+}
+
 func (typeConstraintCache *TypeConstraintDifferCache) findConstraintsForAndGroup(andGroup *boundschecking.AndGroup, fromConstraints TypeConstraints) (*boundschecking.AndGroup, error) {
 	var resultProductGroup []*boundschecking.SumGroup = nil
 
-	if fromConstraints.constraints == nil || len(fromConstraints.contradictions) == 0 {
+	if fromConstraints.constraints == nil || len(fromConstraints.constraints.AndGroups) == 0 {
 		return andGroup, nil
 	}
 
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"zen/boundschecking"
 	"zen/parser"
+	"zen/source"
 	"zen/test"
 	"zen/typechecker"
 )
@@ -61,3 +62,26 @@ func TestTypeDiff(t *testing.T) {
 
 	test.Assert(t, len(typeDiff.additionalConstrants.AndGroups) == 2, "Should require constraints to go one way")
 }
+
+func TestGenericTypeConstraints(t *testing.T) {
+	var fileDef, parseErrors = parser.Parse(source.SourceFromString(`
+		type Sized(T) [value: T, size: u32] where size > 0
+		type Values [a: Sized(i32), b: Sized(i32), c: Sized(bool)]
+	`))
+	test.Assert(t, len(parseErrors) == 0, "Should parse")
+	test.Assert(t, len(typechecker.CheckTypes(fileDef)) == 0, "Should type check")
+
+	var values = fileDef.Definitions[1].(*parser.TypeDefinition).Type.(*parser.StructureTypeType)
+	var nodeState = boundschecking.NewNormalizerState()
+	var typeConstraintDiffer = NewTypeConstraintDifferCache(nodeState)
+
+	test.Assert(t, values.Entries[0].Type == values.Entries[1].Type, "Instances with the same arguments should be shared")
+	test.Assert(t, values.Entries[0].Type != values.Entries[2].Type, "Each instance should be a new type")
+
+	sizedInt, err := typeConstraintDiffer.GetConstraintsForType(values.Entries[0].Type)
+	test.Assert(t, err == nil && sizedInt.constraints != nil, "Instances should have the where expression")
+
+	sizedBool, err := typeConstraintDiffer.GetConstraintsForType(values.Entries[2].Type)
+	test.Assert(t, err == nil && sizedBool.constraints != nil, "Instances should have the where expression")
+	test.Assert(t, sizedInt.variables[0].at != sizedBool.variables[0].at, "Instances should constrain their own values")
+}
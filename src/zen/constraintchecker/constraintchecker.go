@@ -1,6 +1,8 @@
 package constraintchecker
 
 import (
+	"math"
+	"sort"
 	"zen/boundschecking"
 	"zen/parser"
 	"zen/tokenizer"
@@ -12,6 +14,9 @@ type ConstraintChecker struct {
 	normalizerState   *boundschecking.NormalizerState
 	errors            []parser.ParseError
 	typeDiffer        *TypeConstraintDifferCache
+	contracts         map[int]*functionContract
+	declarationTypes  map[int]parser.TypeNode
+	outcomes          *outcomeTracker
 }
 
 func NewConstrantChecker() *ConstraintChecker {
@@ -23,6 +28,9 @@ func NewConstrantChecker() *ConstraintChecker {
 		normalizerState,
 		nil,
 		NewTypeConstraintDifferCache(normalizerState),
+		make(map[int]*functionContract),
+		make(map[int]parser.TypeNode),
+		newOutcomeTracker(),
 	}
 }
 
@@ -41,12 +49,325 @@ func (constraintChecker *ConstraintChecker) createState() *ConstraintCheckerStat
 	} else {
 		result = constraintChecker.checkerStateStack[len(constraintChecker.checkerStateStack)-1].Copy()
 	}
+	result.parentMapping = constraintChecker.normalizerState.GetIdentifierMapping()
 	constraintChecker.checkerStateStack = append(constraintChecker.checkerStateStack, result)
 	return result
 }
 
 func (constraintChecker *ConstraintChecker) popState() {
+	var poppedState = constraintChecker.peekState()
+	var restoredInvariants = constraintChecker.takeRestoredInvariants(poppedState)
 	constraintChecker.checkerStateStack = constraintChecker.checkerStateStack[:len(constraintChecker.checkerStateStack)-1]
+	constraintChecker.normalizerState.RestoreIdentifierMapping(poppedState.parentMapping)
+	constraintChecker.invalidateDeclarations(poppedState.modifiedDeclarations)
+
+	var parentState = constraintChecker.peekState()
+
+	if parentState == nil {
+		return
+	}
+
+	for _, broken := range poppedState.brokenInvariants {
+		parentState.addBrokenInvariant(broken)
+	}
+
+	// a restored invariant holds after the branch unless it was already
+	// broken before it
+	for _, restored := range restoredInvariants {
+		if !parentState.hasBrokenInvariant(restored.path) {
+			node, err := constraintChecker.normalizerState.NormalizeToNode(restored.expression)
+
+			if err == nil {
+				constraintChecker.assumeInvariants(node, restored.typeNode, 0)
+			}
+		}
+	}
+}
+
+// Takes the top state off of the stack without changing the state below it
+// so it can still be committed once other branches are checked. Invariants
+// restored by the end of the state are no longer broken
+func (constraintChecker *ConstraintChecker) detachState() (*ConstraintCheckerState, boundschecking.IdentifierMapping) {
+	var state = constraintChecker.peekState()
+	var mapping = constraintChecker.normalizerState.GetIdentifierMapping()
+	constraintChecker.takeRestoredInvariants(state)
+	constraintChecker.checkerStateStack = constraintChecker.checkerStateStack[:len(constraintChecker.checkerStateStack)-1]
+	constraintChecker.normalizerState.RestoreIdentifierMapping(state.parentMapping)
+	return state, mapping
+}
+
+// When a branch is the only way to continue past a statement everything
+// known at its end is known after the statement. Variables declared inside
+// of the branch go out of scope
+func (constraintChecker *ConstraintChecker) commitState(state *ConstraintCheckerState, mapping boundschecking.IdentifierMapping) {
+	var parentState = constraintChecker.peekState()
+	var normalizerState = constraintChecker.normalizerState
+
+	parentState.knownConstraints = state.knownConstraints
+	parentState.brokenInvariants = state.brokenInvariants
+
+	for declaration := range state.modifiedDeclarations {
+		parentState.modifiedDeclarations[declaration] = true
+	}
+
+	normalizerState.RestoreIdentifierMapping(mapping)
+
+	// a variable that was assigned before being shadowed lost its value
+	// from before the branch
+	for _, name := range normalizerState.RestoreDeclarations(state.parentMapping) {
+		source, _ := normalizerState.GetIdentifierSource(name)
+
+		if state.modifiedDeclarations[source.Declaration] {
+			var reference = normalizerState.ReassignIdentifier(name)
+			constraintChecker.assumeTypeRange(reference, constraintChecker.declarationTypes[source.Declaration], 0)
+		}
+	}
+}
+
+// The most disjuncts kept after joining branches. Past it the join forgets
+// what the branches assigned like popState does
+const maxJoinedConstraints = 32
+
+// When several branches fall through the code after them is reached through
+// any one of them. A variable assigned in a branch gets a new value that is
+// equal to the value it had at the end of the branch that was taken
+func (constraintChecker *ConstraintChecker) joinStates(states []*ConstraintCheckerState, mappings []boundschecking.IdentifierMapping) {
+	var parentState = constraintChecker.peekState()
+	var normalizerState = constraintChecker.normalizerState
+	var modified = make(map[int]bool)
+	var constraintCount = 0
+
+	for _, state := range states {
+		for declaration := range state.modifiedDeclarations {
+			modified[declaration] = true
+		}
+
+		constraintCount += len(state.knownConstraints)
+	}
+
+	if parentState == nil {
+		return
+	}
+
+	for _, state := range states {
+		for _, broken := range state.brokenInvariants {
+			parentState.addBrokenInvariant(broken)
+		}
+	}
+
+	if constraintCount > maxJoinedConstraints {
+		constraintChecker.invalidateDeclarations(modified)
+		return
+	}
+
+	var names = normalizerState.GetIdentifierMapping().NamesForDeclarations(modified)
+	sort.Strings(names)
+
+	// the new value gets the range of its type before it is equated to the
+	// value from the branch
+	for _, name := range names {
+		source, _ := normalizerState.GetIdentifierSource(name)
+		var typeNode = constraintChecker.declarationTypes[source.Declaration]
+		var joined = normalizerState.ReassignIdentifier(name)
+
+		for stateIndex, state := range states {
+			constraintChecker.checkerStateStack = append(constraintChecker.checkerStateStack, state)
+			constraintChecker.assumeTypeRange(joined, typeNode, 0)
+
+			branchSource, ok := mappings[stateIndex].Source(name)
+
+			if ok && branchSource.Declaration == source.Declaration {
+				constraintChecker.assumeSameFields(normalizerState.CreateVariableReference(name, branchSource.UniqueId), joined, typeNode, nil, true, 0)
+			}
+
+			constraintChecker.checkerStateStack = constraintChecker.checkerStateStack[:len(constraintChecker.checkerStateStack)-1]
+		}
+	}
+
+	parentState.knownConstraints = nil
+
+	for _, state := range states {
+		parentState.knownConstraints = append(parentState.knownConstraints, state.knownConstraints...)
+	}
+
+	for declaration := range modified {
+		parentState.modifiedDeclarations[declaration] = true
+	}
+}
+
+// Variables assigned inside of a popped state could hold any value
+// they were given so they are reassigned to forget what was known about them.
+// They still hold a value of their type
+func (constraintChecker *ConstraintChecker) invalidateDeclarations(declarations map[int]bool) {
+	var state = constraintChecker.peekState()
+
+	if state == nil || len(declarations) == 0 {
+		return
+	}
+
+	for declaration := range declarations {
+		state.modifiedDeclarations[declaration] = true
+	}
+
+	var mapping = constraintChecker.normalizerState.GetIdentifierMapping()
+
+	for _, name := range mapping.NamesForDeclarations(declarations) {
+		var reference = constraintChecker.normalizerState.ReassignIdentifier(name)
+		source, _ := constraintChecker.normalizerState.GetIdentifierSource(name)
+		constraintChecker.assumeTypeRange(reference, constraintChecker.declarationTypes[source.Declaration], 0)
+	}
+}
+
+func (constraintChecker *ConstraintChecker) assumeEquality(at tokenizer.SourceLocation, sumGroup *boundschecking.SumGroup, node boundschecking.NormalizedNode) {
+	var rules = constraintChecker.normalizerState.CreateEquality(sumGroup, node)
+	_, err := constraintChecker.peekState().addSumGroups(rules)
+
+	if err != nil {
+		constraintChecker.reportErrorMessage(at, "Could not append to known data")
+	}
+}
+
+// Structures nested deeper than this don't get range facts for their fields
+const maxTypeRangeDepth = 4
+
+// The smallest value of i64 is left out of its range since it can't be
+// negated in the 64 bit arithmetic used to check ranges
+func integerRange(integerType *parser.IntegerType) (min int64, max int64) {
+	min = integerType.MinValue()
+
+	if min == math.MinInt64 {
+		min = min + 1
+	}
+
+	return min, integerType.MaxValue()
+}
+
+// Integers are kept within the range of their type
+func (constraintChecker *ConstraintChecker) assumeTypeRange(node boundschecking.NormalizedNode, typeNode parser.TypeNode, depth int) {
+	var state = constraintChecker.peekState()
+
+	if state == nil || node == nil || typeNode == nil || depth > maxTypeRangeDepth {
+		return
+	}
+
+	var normalizerState = constraintChecker.normalizerState
+
+	if asInteger, ok := typeNode.(*parser.IntegerType); ok {
+		var min, max = integerRange(asInteger)
+		state.addSumGroups(normalizerState.CreateRange(normalizerState.SumGroupFromNode(node), min, max))
+	} else if asStructure, ok := typeNode.(*parser.StructureTypeType); ok {
+		for _, entry := range asStructure.Entries {
+			constraintChecker.assumeTypeRange(normalizerState.CreatePropertyReference(node, entry.Name, 0), entry.Type, depth+1)
+		}
+	} else if _, ok := typeNode.(*parser.BooleanType); ok {
+		state.addSumGroups(normalizerState.CreateRange(normalizerState.SumGroupFromNode(node), 0, 1))
+	} else if _, ok := typeNode.(*parser.ArrayTypeType); ok {
+		var length = normalizerState.CreatePropertyReference(node, "length", 0)
+		var lengthType = parser.NewIntegerType(32, false)
+		state.addSumGroups(normalizerState.CreateRange(normalizerState.SumGroupFromNode(length), lengthType.MinValue(), lengthType.MaxValue()))
+	}
+}
+
+// The value of an expression as a sum. Comparisons and other conditions
+// don't have one so the boolean they evaluate to is linked to a new value
+func (constraintChecker *ConstraintChecker) normalizeValue(value parser.Expression) (*boundschecking.SumGroup, error) {
+	var normalizerState = constraintChecker.normalizerState
+	sumGroup, err := normalizerState.NormalizeToSumGroup(value)
+	var state = constraintChecker.peekState()
+
+	if err == nil || state == nil || value.GetType() == nil || value.GetType().GetNodeType() != parser.BooleanNodeType {
+		return sumGroup, err
+	}
+
+	var node = normalizerState.CreateVariableReference("condition", parser.NextUniqueId())
+	var link = normalizerState.CreateBooleanLink(node, normalizerState.NormalizeToOrGroup(value))
+
+	if link == nil {
+		return nil, err
+	}
+
+	constraintChecker.assumeTypeRange(node, value.GetType(), 0)
+	state.addRules(link.AndGroups)
+	normalizerState.UseExpressionNode(value, node)
+
+	return normalizerState.SumGroupFromNode(node), nil
+}
+
+// Converting an integer to a type with a smaller range is only allowed when
+// the value is known to fit in the new type
+func (constraintChecker *ConstraintChecker) checkFits(value parser.Expression, targetType parser.TypeNode) bool {
+	var state = constraintChecker.peekState()
+
+	if targetFunction, ok := targetType.(*parser.FunctionTypeType); ok && state != nil {
+		return constraintChecker.checkFunctionContract(value, targetFunction)
+	}
+
+	literal, isLiteral := value.(*parser.StructureExpression)
+	targetStructure, ok := targetType.(*parser.StructureTypeType)
+
+	if isLiteral && ok && state != nil {
+		return constraintChecker.checkStructureLiteral(literal, targetStructure)
+	}
+
+	targetInteger, ok := targetType.(*parser.IntegerType)
+
+	if state == nil || !ok || value.GetType() == nil {
+		return true
+	}
+
+	valueInteger, ok := value.GetType().(*parser.IntegerType)
+
+	if !ok || targetInteger.ContainsRange(valueInteger) {
+		return true
+	}
+
+	var normalizerState = constraintChecker.normalizerState
+	var min, max = integerRange(targetInteger)
+	sumGroup, err := normalizerState.NormalizeToSumGroup(value)
+
+	if err == nil {
+		var rangeRules = normalizerState.CreateAndGroup(normalizerState.CreateRangeCheck(sumGroup, min, max))
+		result, checkErr := state.checkAndGroup(rangeRules)
+
+		if checkErr == nil && len(result) == 0 {
+			return true
+		}
+	}
+
+	constraintChecker.reportErrorMessage(value.Begin(), "Value may not fit in "+targetInteger.Name())
+	return false
+}
+
+// Functions can only be used as a function type whose contract they satisfy
+func (constraintChecker *ConstraintChecker) checkFunctionContract(value parser.Expression, targetType *parser.FunctionTypeType) bool {
+	valueFunction, ok := value.GetType().(*parser.FunctionTypeType)
+
+	if !ok {
+		return true
+	}
+
+	diff, err := constraintChecker.typeDiffer.GetTypeDiff(valueFunction, targetType)
+
+	if err != nil {
+		constraintChecker.reportErrorMessage(value.Begin(), err.Error())
+		return false
+	} else if diff.additionalConstrants == nil {
+		return true
+	}
+
+	var conditions []*boundschecking.SumGroup = nil
+
+	for _, andGroup := range diff.additionalConstrants.AndGroups {
+		conditions = append(conditions, andGroup.SumGroups...)
+	}
+
+	constraintChecker.reportError(parser.CreateSpanErrorWithMultipleLocations(
+		value.Begin(),
+		value.End(),
+		"Could not verify function satisfies the contract of its type",
+		formatErrorWithConstraints(diff.expressionMapping, "With condition at\n", conditions),
+	))
+	return false
 }
 
 func (constraintChecker *ConstraintChecker) peekState() *ConstraintCheckerState {
@@ -78,28 +399,304 @@ func (constraintChecker *ConstraintChecker) VisitNumber(number *parser.Number) {
 }
 
 func (constraintChecker *ConstraintChecker) VisitUnaryExpression(exp *parser.UnaryExpression) {
-
+	exp.Expr.Accept(constraintChecker)
 }
 
 func (constraintChecker *ConstraintChecker) VisitPropertyExpression(exp *parser.PropertyExpression) {
+	exp.Left.Accept(constraintChecker)
+}
+
+var operationNames = map[tokenizer.TokenType]string{
+	tokenizer.AddToken:      "addition",
+	tokenizer.MinusToken:    "subtraction",
+	tokenizer.MultiplyToken: "multiplication",
+	tokenizer.DivideToken:   "division",
+}
+
+func isWrappingOperator(tokenType tokenizer.TokenType) bool {
+	return tokenType == tokenizer.WrapAddToken ||
+		tokenType == tokenizer.WrapMinusToken ||
+		tokenType == tokenizer.WrapMultiplyToken
+}
 
+func isDivisionOperator(tokenType tokenizer.TokenType) bool {
+	return tokenType == tokenizer.DivideToken || tokenType == tokenizer.ModToken
+}
+
+func (constraintChecker *ConstraintChecker) checkDivisor(divisor parser.Expression) {
+	var state = constraintChecker.peekState()
+
+	if state == nil {
+		return
+	}
+
+	var normalizerState = constraintChecker.normalizerState
+	value, err := normalizerState.NormalizeToSumGroup(divisor)
+
+	if err == nil {
+		var notZero = normalizerState.CreateOrGroup([]*boundschecking.AndGroup{
+			normalizerState.CreateAndGroup([]*boundschecking.SumGroup{normalizerState.CreateLowerBound(value, 1)}),
+			normalizerState.CreateAndGroup([]*boundschecking.SumGroup{normalizerState.CreateUpperBound(value, -1)}),
+		})
+
+		result, checkErr := state.checkOrGroup(notZero)
+
+		if checkErr == nil && len(result) == 0 {
+			return
+		}
+	}
+
+	var conditionLocations []parser.ParseError = nil
+
+	for _, condition := range state.branchConditions {
+		var message = "With branch condition at\n"
+
+		if !condition.isTrue {
+			message = "With negated branch condition at\n"
+		}
+
+		conditionLocations = append(conditionLocations, parser.CreateSpanError(condition.expression.Begin(), condition.expression.End(), message))
+	}
+
+	constraintChecker.reportError(parser.CreateSpanErrorWithMultipleLocations(
+		divisor.Begin(),
+		divisor.End(),
+		"Could not verify divisor is not zero",
+		conditionLocations,
+	))
 }
 
 func (constraintChecker *ConstraintChecker) VisitBinaryExpression(exp *parser.BinaryExpression) {
+	exp.Left.Accept(constraintChecker)
+	exp.Right.Accept(constraintChecker)
+
+	resultType, ok := exp.Type.(*parser.IntegerType)
+
+	if !ok {
+		return
+	}
+
+	var normalizerState = constraintChecker.normalizerState
+	var operatorType = exp.Operator.TokenType
+
+	// the value of a wrapped or divided result isn't a sum of its operands
+	// so it is tracked as a new value within the range of its type, unless
+	// both operands are known and the division is folded
+	var folded = false
 
+	if isDivisionOperator(operatorType) {
+		_, err := normalizerState.NormalizeToSumGroup(exp)
+		folded = err == nil
+	}
+
+	if isWrappingOperator(operatorType) || isDivisionOperator(operatorType) && !folded {
+		var result = normalizerState.CreateVariableReference(exp.Operator.Value, parser.NextUniqueId())
+		normalizerState.UseExpressionNode(exp, result)
+		constraintChecker.assumeTypeRange(result, resultType, 0)
+	}
+
+	if isDivisionOperator(operatorType) {
+		constraintChecker.checkDivisor(exp.Right)
+	}
+
+	operationName, ok := operationNames[operatorType]
+	var state = constraintChecker.peekState()
+
+	if !ok || state == nil {
+		return
+	}
+
+	var min, max = integerRange(resultType)
+	var noOverflow *boundschecking.OrGroup = nil
+
+	if operatorType == tokenizer.DivideToken {
+		// only the smallest signed value divided by -1 can overflow
+		left, leftErr := normalizerState.NormalizeToSumGroup(exp.Left)
+		right, rightErr := normalizerState.NormalizeToSumGroup(exp.Right)
+
+		if !resultType.IsSigned {
+			return
+		} else if leftErr == nil && rightErr == nil {
+			noOverflow = normalizerState.CreateOrGroup([]*boundschecking.AndGroup{
+				normalizerState.CreateAndGroup([]*boundschecking.SumGroup{normalizerState.CreateLowerBound(left, min+1)}),
+				normalizerState.CreateAndGroup([]*boundschecking.SumGroup{normalizerState.CreateLowerBound(right, 0)}),
+				normalizerState.CreateAndGroup([]*boundschecking.SumGroup{normalizerState.CreateUpperBound(right, -2)}),
+			})
+		}
+	} else {
+		result, err := normalizerState.NormalizeToSumGroup(exp)
+
+		if err == nil {
+			noOverflow = normalizerState.CreateOrGroup([]*boundschecking.AndGroup{
+				normalizerState.CreateAndGroup(normalizerState.CreateRangeCheck(result, min, max)),
+			})
+		}
+	}
+
+	if noOverflow != nil {
+		result, err := state.checkOrGroup(noOverflow)
+
+		if err == nil && len(result) == 0 {
+			return
+		}
+	}
+
+	constraintChecker.reportErrorMessage(exp.Operator.At, "possible overflow of "+resultType.Name()+" "+operationName)
 }
 
 func (constraintChecker *ConstraintChecker) VisitStructureExpression(exp *parser.StructureExpression) {
+	for _, entry := range exp.Entries {
+		entry.Expr.Accept(constraintChecker)
+	}
+
+	if constraintChecker.peekState() == nil || exp.Type == nil {
+		return
+	}
 
+	var node = constraintChecker.normalizerState.CreateVariableReference("literal", parser.NextUniqueId())
+	constraintChecker.assumeLiteralFields(node, exp)
+	constraintChecker.normalizerState.UseExpressionNode(exp, node)
+}
+
+func (constraintChecker *ConstraintChecker) getContract(fnType *parser.FunctionTypeType) *functionContract {
+	result, ok := constraintChecker.contracts[fnType.UniqueId()]
+
+	if !ok {
+		result = newFunctionContract(constraintChecker.normalizerState, fnType)
+		constraintChecker.contracts[fnType.UniqueId()] = result
+	}
+
+	return result
+}
+
+func (constraintChecker *ConstraintChecker) VisitCallExpression(exp *parser.CallExpression) {
+	exp.Function.Accept(constraintChecker)
+
+	var arguments []*boundschecking.SumGroup = nil
+
+	for _, argument := range exp.Arguments {
+		argument.Accept(constraintChecker)
+		sumGroup, _ := constraintChecker.normalizeValue(argument)
+		arguments = append(arguments, sumGroup)
+	}
+
+	var fnType = exp.FunctionType
+
+	if fnType == nil {
+		return
+	}
+
+	if len(fnType.Input.Entries) == len(exp.Arguments) {
+		for index, argument := range exp.Arguments {
+			if !constraintChecker.checkFits(argument, fnType.Input.Entries[index].Type) {
+				arguments[index] = nil
+			}
+		}
+	}
+
+	constraintChecker.checkInvariants(exp.Begin(), "before call")
+
+	var postArguments = constraintChecker.invalidateArguments(exp.Arguments)
+	call, callErr := constraintChecker.getContract(fnType).instantiate(constraintChecker.normalizerState, arguments, postArguments)
+
+	for index, output := range call.outputs {
+		constraintChecker.assumeTypeFacts(output, fnType.Output.Entries[index].Type)
+	}
+
+	if len(call.outputs) == 1 {
+		constraintChecker.normalizerState.UseExpressionNode(exp, call.outputs[0])
+	}
+
+	var state = constraintChecker.peekState()
+
+	if state == nil {
+		return
+	}
+
+	if len(call.outputs) > 1 {
+		// the outputs are the fields of the result so they can be used
+		// by name or destructured
+		var result = constraintChecker.normalizerState.CreateVariableReference("result", parser.NextUniqueId())
+
+		for index, output := range call.outputs {
+			var field = fnType.Output.Entries[index]
+			constraintChecker.assumeSameFields(output, constraintChecker.normalizerState.CreatePropertyReference(result, field.Name, 0), field.Type, nil, true, 0)
+		}
+
+		constraintChecker.normalizerState.UseExpressionNode(exp, result)
+	}
+
+	var preConditionsHold = true
+
+	if callErr != nil {
+		constraintChecker.reportErrorMessage(exp.Begin(), callErr.Error())
+		preConditionsHold = false
+	} else if call.preConditions != nil {
+		result, err := state.checkOrGroup(call.preConditions)
+
+		if err != nil {
+			constraintChecker.reportErrorMessage(exp.Begin(), err.Error())
+			preConditionsHold = false
+		} else if len(result) > 0 {
+			constraintChecker.reportError(parser.CreateErrorWithMultipleLocations(
+				exp.Begin(),
+				"Could not verify preconditions",
+				formatErrorWithConstraints(call.expressionMapping, "With precondition at\n", result),
+			))
+			preConditionsHold = false
+		}
+	}
+
+	if preConditionsHold {
+		_, err := state.addRules(call.postConditions)
+
+		if err != nil {
+			constraintChecker.reportErrorMessage(exp.Begin(), "Could not append to known data")
+		}
+	}
+}
+
+func (constraintChecker *ConstraintChecker) VisitIndexExpression(exp *parser.IndexExpression) {
+	exp.Left.Accept(constraintChecker)
+	exp.Index.Accept(constraintChecker)
+
+	var state = constraintChecker.peekState()
+
+	if state == nil {
+		return
+	}
+
+	index, indexErr := constraintChecker.normalizerState.NormalizeToSumGroup(exp.Index)
+	array, arrayErr := constraintChecker.normalizerState.NormalizeToNode(exp.Left)
+
+	if indexErr != nil || arrayErr != nil {
+		constraintChecker.reportErrorMessage(exp.Index.Begin(), "Could not verify index is within the bounds of the array")
+		return
+	}
+
+	var length = constraintChecker.normalizerState.CreatePropertyReference(array, "length", 0)
+	var bounds = constraintChecker.normalizerState.CreateIndexBounds(index, length)
+
+	result, err := state.checkAndGroup(bounds)
+
+	if err != nil {
+		constraintChecker.reportErrorMessage(exp.Index.Begin(), err.Error())
+	} else if len(result) > 0 {
+		constraintChecker.reportErrorMessage(exp.Index.Begin(), "Could not verify index is within the bounds of the array")
+	}
 }
 
 func (constraintChecker *ConstraintChecker) VisitFunction(function *parser.Function) {
+	var previousMapping = constraintChecker.normalizerState.GetIdentifierMapping()
+
 	for _, input := range function.Type.Input.Entries {
 		constraintChecker.normalizerState.UseIdentifierMapping(input.Name, input.UniqueId)
+		constraintChecker.declarationTypes[input.UniqueId] = input.Type
 	}
 
 	for _, output := range function.Type.Output.Entries {
 		constraintChecker.normalizerState.UseIdentifierMapping(output.Name, output.UniqueId)
+		constraintChecker.declarationTypes[output.UniqueId] = output.Type
 	}
 
 	var functionStackFrame = newFunctionStackFrame(constraintChecker.normalizerState, function.Type)
@@ -109,42 +706,378 @@ func (constraintChecker *ConstraintChecker) VisitFunction(function *parser.Funct
 		state := constraintChecker.createState()
 		functionStackFrame.currentCondition = index
 
+		// a lambda knows about the values it captures but the structures
+		// the function around it assigned are not its responsibility
+		state.brokenInvariants = nil
+
+		for _, entry := range function.Type.Input.Entries {
+			var reference = constraintChecker.normalizerState.CreateVariableReference(entry.Name, entry.UniqueId)
+			constraintChecker.assumeTypeFacts(reference, entry.Type)
+		}
+
+		for _, entry := range function.Type.Output.Entries {
+			var reference = constraintChecker.normalizerState.CreateVariableReference(entry.Name, entry.UniqueId)
+			constraintChecker.assumeTypeRange(reference, entry.Type, 0)
+		}
+
+		var reachable = len(state.knownConstraints) > 0
+
 		if conditions.preConditions != nil {
 			state.addRules([]*boundschecking.AndGroup{conditions.preConditions})
 		}
 
+		if reachable {
+			var consistent = len(state.knownConstraints) > 0
+			constraintChecker.outcomes.record(function, consistent, !consistent)
+		}
+
 		function.Body.Accept(constraintChecker)
 
+		// functions with outputs have to return them so only functions
+		// without outputs can reach the end of their body
+		if parser.CanFallThrough(function.Body) {
+			constraintChecker.checkInvariants(function.Body.End(), "at function exit")
+
+			if len(function.Type.Output.Entries) == 0 {
+				constraintChecker.checkPostConditions(function.Body.End(), nil)
+			}
+		}
+
 		constraintChecker.popState()
 	}
 
 	constraintChecker.functionStack = constraintChecker.functionStack[:len(constraintChecker.functionStack)-1]
+	constraintChecker.normalizerState.RestoreIdentifierMapping(previousMapping)
 
+	if len(constraintChecker.functionStack) == 0 {
+		constraintChecker.reportOutcomes()
+	}
 }
 
+// A branch that can't fall through doesn't tell the statements after the if
+// anything so when only one branch falls through what is known at its end
+// is known after the if. Conditions that can only go one way are reported
 func (constraintChecker *ConstraintChecker) VisitIf(ifStatement *parser.IfStatement) {
 	ifStatement.Expresssion.Accept(constraintChecker)
 	var expresssionRules = constraintChecker.normalizerState.NormalizeToOrGroup(ifStatement.Expresssion)
 
+	var bodyFallsThrough = parser.CanFallThrough(ifStatement.Body)
+	var elseFallsThrough = ifStatement.ElseBody == nil || parser.CanFallThrough(ifStatement.ElseBody)
+	var states []*ConstraintCheckerState = nil
+	var mappings []boundschecking.IdentifierMapping = nil
+	var parentState = constraintChecker.peekState()
+	var reachable = parentState != nil && len(parentState.knownConstraints) > 0
+
 	var ifBodyState = constraintChecker.createState()
-	_, err := ifBodyState.addRules(expresssionRules.AndGroups)
+	ifBodyState.branchConditions = append(ifBodyState.branchConditions, branchCondition{ifStatement.Expresssion, true})
+	canBeTrue, bodyErr := ifBodyState.addRules(expresssionRules.AndGroups)
+	if bodyErr != nil {
+		constraintChecker.reportErrorMessage(ifStatement.Expresssion.Begin(), bodyErr.Error())
+	}
+	ifStatement.Body.Accept(constraintChecker)
+
+	var ifState, ifMapping = constraintChecker.detachState()
+
+	if bodyFallsThrough {
+		states = append(states, ifState)
+		mappings = append(mappings, ifMapping)
+	}
+
+	var elseBodyState = constraintChecker.createState()
+	elseBodyState.branchConditions = append(elseBodyState.branchConditions, branchCondition{ifStatement.Expresssion, false})
+	canBeFalse, err := elseBodyState.addRules(constraintChecker.normalizerState.NotOrGroup(expresssionRules).AndGroups)
 	if err != nil {
 		constraintChecker.reportErrorMessage(ifStatement.Expresssion.Begin(), err.Error())
+	} else if reachable && bodyErr == nil {
+		constraintChecker.outcomes.record(
+			ifStatement,
+			canBeTrue || isMarkedUnreachable(ifStatement.Body),
+			canBeFalse || isMarkedUnreachable(ifStatement.ElseBody),
+		)
 	}
-	ifStatement.Body.Accept(constraintChecker)
-	constraintChecker.popState()
 
 	if ifStatement.ElseBody != nil {
-		var elseBodyState = constraintChecker.createState()
-		_, err = elseBodyState.addRules(constraintChecker.normalizerState.NotOrGroup(expresssionRules).AndGroups)
-		if err != nil {
-			constraintChecker.reportErrorMessage(ifStatement.Expresssion.Begin(), err.Error())
-		}
 		ifStatement.ElseBody.Accept(constraintChecker)
+	}
+
+	var elseState, elseMapping = constraintChecker.detachState()
+
+	if elseFallsThrough {
+		states = append(states, elseState)
+		mappings = append(mappings, elseMapping)
+	}
+
+	if len(states) == 1 {
+		constraintChecker.commitState(states[0], mappings[0])
+	} else {
+		constraintChecker.joinStates(states, mappings)
+	}
+}
+
+// Anything assigned in the body of a loop could hold any value at the start
+// of an iteration. The body is checked once without reporting errors or
+// condition outcomes to find what it assigns, popping its state then forgets
+// those values
+func (constraintChecker *ConstraintChecker) forgetLoopAssignments(body *parser.Body) {
+	var errorCount = len(constraintChecker.errors)
+	var outcomes = constraintChecker.outcomes
+	constraintChecker.outcomes = newOutcomeTracker()
+
+	constraintChecker.createState()
+	body.Accept(constraintChecker)
+	constraintChecker.popState()
+
+	constraintChecker.errors = constraintChecker.errors[:errorCount]
+	constraintChecker.outcomes = outcomes
+}
+
+func (constraintChecker *ConstraintChecker) checkLoopInvariant(invariant parser.Expression, message string) {
+	var state = constraintChecker.peekState()
+
+	if invariant == nil || state == nil {
+		return
+	}
+
+	result, err := state.checkOrGroup(constraintChecker.normalizerState.NormalizeToOrGroup(invariant))
+
+	if err != nil {
+		constraintChecker.reportErrorMessage(invariant.Begin(), err.Error())
+	} else if len(result) > 0 {
+		constraintChecker.reportError(parser.CreateSpanError(invariant.Begin(), invariant.End(), message))
+	}
+}
+
+func (constraintChecker *ConstraintChecker) assumeCondition(condition parser.Expression, isTrue bool) {
+	var rules = constraintChecker.normalizerState.NormalizeToOrGroup(condition)
+
+	if !isTrue {
+		rules = constraintChecker.normalizerState.NotOrGroup(rules)
+	}
+
+	_, err := constraintChecker.peekState().addRules(rules.AndGroups)
+
+	if err != nil {
+		constraintChecker.reportErrorMessage(condition.Begin(), err.Error())
+	}
+}
+
+// The measure of a loop has to be non negative when an iteration starts and
+// smaller at the end of it. Returns the measure at the start of the iteration
+func (constraintChecker *ConstraintChecker) checkMeasureNotNegative(measure parser.Expression) *boundschecking.SumGroup {
+	var normalizerState = constraintChecker.normalizerState
+	value, err := normalizerState.NormalizeToSumGroup(measure)
+
+	if err != nil {
+		constraintChecker.reportErrorMessage(measure.Begin(), err.Error())
+		return nil
+	}
+
+	result, err := constraintChecker.peekState().checkAndGroup(normalizerState.CreateAndGroup([]*boundschecking.SumGroup{
+		normalizerState.CreateLowerBound(value, 0),
+	}))
+
+	if err != nil || len(result) > 0 {
+		constraintChecker.reportError(parser.CreateSpanError(measure.Begin(), measure.End(), "Could not verify loop measure is not negative"))
+	}
+
+	return value
+}
+
+func (constraintChecker *ConstraintChecker) checkMeasureDecreases(measure parser.Expression, before *boundschecking.SumGroup) {
+	var normalizerState = constraintChecker.normalizerState
+	after, err := normalizerState.NormalizeToSumGroup(measure)
+
+	if err != nil {
+		return
+	}
+
+	result, err := constraintChecker.peekState().checkAndGroup(normalizerState.CreateAndGroup([]*boundschecking.SumGroup{
+		normalizerState.CreateLessThan(after, before),
+	}))
+
+	if err != nil || len(result) > 0 {
+		constraintChecker.reportError(parser.CreateSpanError(measure.Begin(), measure.End(), "Could not verify loop measure decreases"))
+	}
+}
+
+// Loops are checked with the invariant as the only thing known about the
+// values the body assigns. The invariant has to hold when the loop is reached
+// and after every iteration so it holds along with the negated condition
+// once the loop is done
+func (constraintChecker *ConstraintChecker) VisitWhile(whileStatement *parser.WhileStatement) {
+	if constraintChecker.peekState() == nil {
+		return
+	}
+
+	constraintChecker.checkLoopInvariant(whileStatement.Invariant, "Could not verify loop invariant holds on entry")
+	constraintChecker.forgetLoopAssignments(whileStatement.Body)
+
+	var loopState = constraintChecker.createState()
+
+	if whileStatement.Invariant != nil {
+		constraintChecker.assumeCondition(whileStatement.Invariant, true)
+	}
+
+	whileStatement.Condition.Accept(constraintChecker)
+	loopState.branchConditions = append(loopState.branchConditions, branchCondition{whileStatement.Condition, true})
+	constraintChecker.assumeCondition(whileStatement.Condition, true)
+
+	var measure *boundschecking.SumGroup = nil
+
+	if whileStatement.Decreases != nil {
+		measure = constraintChecker.checkMeasureNotNegative(whileStatement.Decreases)
+	}
+
+	whileStatement.Body.Accept(constraintChecker)
+
+	// a body that returns leaves the loop so it doesn't start another
+	// iteration
+	if parser.CanFallThrough(whileStatement.Body) {
+		constraintChecker.checkLoopInvariant(whileStatement.Invariant, "Could not verify loop invariant is preserved by the loop body")
+
+		if measure != nil {
+			constraintChecker.checkMeasureDecreases(whileStatement.Decreases, measure)
+		}
+	}
+
+	constraintChecker.popState()
+
+	if whileStatement.Invariant != nil {
+		constraintChecker.assumeCondition(whileStatement.Invariant, true)
+	}
+
+	constraintChecker.assumeCondition(whileStatement.Condition, false)
+}
+
+// The body of a foreach loop knows the index is inside of the array it
+// iterates over and nothing else about the values assigned in the loop
+func (constraintChecker *ConstraintChecker) VisitForeach(foreach *parser.ForeachStatement) {
+	foreach.Collection.Accept(constraintChecker)
+
+	if constraintChecker.peekState() == nil {
+		return
+	}
+
+	var normalizerState = constraintChecker.normalizerState
+	collection, collectionErr := normalizerState.NormalizeToNode(foreach.Collection)
+
+	constraintChecker.forgetLoopAssignments(foreach.Body)
+
+	var loopState = constraintChecker.createState()
+
+	normalizerState.UseIdentifierMapping(foreach.Element.Value, foreach.ElementId)
+	asArray, ok := foreach.Collection.GetType().(*parser.ArrayTypeType)
+
+	if ok {
+		var element = normalizerState.CreateVariableReference(foreach.Element.Value, foreach.ElementId)
+		constraintChecker.declarationTypes[foreach.ElementId] = asArray.ElementType
+		constraintChecker.assumeTypeFacts(element, asArray.ElementType)
+	}
+
+	if foreach.Index != nil {
+		var indexType = parser.NewIntegerType(32, false)
+		var index = normalizerState.CreateVariableReference(foreach.Index.Value, foreach.IndexId)
+		normalizerState.UseIdentifierMapping(foreach.Index.Value, foreach.IndexId)
+		constraintChecker.declarationTypes[foreach.IndexId] = indexType
+		constraintChecker.assumeTypeRange(index, indexType, 0)
+
+		if collectionErr == nil {
+			var length = normalizerState.CreatePropertyReference(collection, "length", 0)
+			_, err := loopState.addRules([]*boundschecking.AndGroup{
+				normalizerState.CreateIndexBounds(normalizerState.SumGroupFromNode(index), length),
+			})
+
+			if err != nil {
+				constraintChecker.reportErrorMessage(foreach.Begin(), err.Error())
+			}
+		}
+	}
+
+	foreach.Body.Accept(constraintChecker)
+	constraintChecker.popState()
+}
+
+// The where expression of the variant an arm is for holds for the matched
+// value inside of the arm
+func (constraintChecker *ConstraintChecker) VisitMatch(match *parser.MatchStatement) {
+	match.Subject.Accept(constraintChecker)
+	node, err := constraintChecker.normalizerState.NormalizeToNode(match.Subject)
+
+	for _, arm := range match.Arms {
+		constraintChecker.createState()
+
+		if err == nil && arm.Type != nil {
+			constraintChecker.assumeTypeFacts(node, arm.Type)
+		}
+
+		arm.Body.Accept(constraintChecker)
 		constraintChecker.popState()
 	}
 }
 
+// The value of a match is equal to the value of the arm that was taken. Each
+// arm links the value in its own state and the states are joined like the
+// branches of an if
+func (constraintChecker *ConstraintChecker) VisitMatchExpression(match *parser.MatchExpression) {
+	match.Subject.Accept(constraintChecker)
+
+	var normalizerState = constraintChecker.normalizerState
+	node, err := normalizerState.NormalizeToNode(match.Subject)
+	var result = normalizerState.CreateVariableReference("match", parser.NextUniqueId())
+	var states []*ConstraintCheckerState = nil
+	var mappings []boundschecking.IdentifierMapping = nil
+
+	for _, arm := range match.Arms {
+		constraintChecker.createState()
+
+		if err == nil && arm.Type != nil {
+			constraintChecker.assumeTypeFacts(node, arm.Type)
+		}
+
+		arm.Value.Accept(constraintChecker)
+		constraintChecker.assumeArmValue(arm.Value, result, match.Type)
+
+		var state, mapping = constraintChecker.detachState()
+		states = append(states, state)
+		mappings = append(mappings, mapping)
+	}
+
+	if constraintChecker.peekState() == nil {
+		return
+	}
+
+	if len(states) == 1 {
+		constraintChecker.commitState(states[0], mappings[0])
+	} else if len(states) > 1 {
+		constraintChecker.joinStates(states, mappings)
+	}
+
+	normalizerState.UseExpressionNode(match, result)
+}
+
+// Like a joined variable the result gets the range of its type before it is
+// equated to the value of the arm
+func (constraintChecker *ConstraintChecker) assumeArmValue(value parser.Expression, result boundschecking.NormalizedNode, resultType parser.TypeNode) {
+	constraintChecker.assumeTypeRange(result, resultType, 0)
+
+	if !constraintChecker.checkFits(value, resultType) {
+		return
+	}
+
+	_, isInteger := resultType.(*parser.IntegerType)
+	_, isBoolean := resultType.(*parser.BooleanType)
+
+	if isInteger || isBoolean {
+		sumGroup, err := constraintChecker.normalizeValue(value)
+
+		if err == nil {
+			constraintChecker.assumeEquality(value.Begin(), sumGroup, result)
+		}
+	} else if valueNode, err := constraintChecker.normalizerState.NormalizeToNode(value); err == nil {
+		constraintChecker.assumeSameFields(valueNode, result, resultType, nil, true, 0)
+	}
+}
+
 func (constraintChecker *ConstraintChecker) VisitBody(body *parser.Body) {
 	for _, statement := range body.Statements {
 		statement.Accept(constraintChecker)
@@ -156,14 +1089,54 @@ func (constraintChecker *ConstraintChecker) VisitReturn(ret *parser.ReturnStatem
 		returnValue.Accept(constraintChecker)
 	}
 
+	constraintChecker.checkInvariants(ret.Begin(), "at function exit")
+	constraintChecker.checkPostConditions(ret.Begin(), ret.ExpressionList)
+}
+
+// Inputs in post clauses stand for the values they have when the function
+// returns so they are replaced by their current values
+func (constraintChecker *ConstraintChecker) postStateSubstitutions(functionStack *functionStackFrame) map[boundschecking.NormalizedNode]*boundschecking.SumGroup {
+	var normalizerState = constraintChecker.normalizerState
+	var result = make(map[boundschecking.NormalizedNode]*boundschecking.SumGroup)
+
+	for _, postInput := range functionStack.postInputs {
+		source, ok := normalizerState.GetIdentifierSource(postInput.Name)
+
+		if ok {
+			result[postInput] = normalizerState.SumGroupFromNode(normalizerState.CreateVariableReference(postInput.Name, source.UniqueId))
+		}
+	}
+
+	return result
+}
+
+func (constraintChecker *ConstraintChecker) checkPostConditions(at tokenizer.SourceLocation, returnValues []parser.Expression) {
 	var functionStack = constraintChecker.peekFunctionStack()
 	postCondition := functionStack.conditions[functionStack.currentCondition].postConditions
 
+	for index, returnValue := range returnValues {
+		if index < len(functionStack.outputTypes) {
+			constraintChecker.checkFits(returnValue, functionStack.outputTypes[index])
+		}
+	}
+
 	if postCondition != nil {
 		var state = constraintChecker.peekState()
 
-		for index, returnValue := range ret.ExpressionList {
-			sumGroup, err := constraintChecker.normalizerState.NormalizeToSumGroup(returnValue)
+		substituted, err := postCondition.SubstituteNodes(
+			constraintChecker.normalizerState,
+			constraintChecker.postStateSubstitutions(functionStack),
+			functionStack.expressionMapping,
+			functionStack.expressionMapping,
+		)
+
+		if err != nil {
+			constraintChecker.reportErrorMessage(at, err.Error())
+			return
+		}
+
+		for index, returnValue := range returnValues {
+			sumGroup, err := constraintChecker.normalizeValue(returnValue)
 
 			if err != nil {
 				constraintChecker.reportErrorMessage(returnValue.Begin(), err.Error())
@@ -175,18 +1148,20 @@ func (constraintChecker *ConstraintChecker) VisitReturn(ret *parser.ReturnStatem
 					constraintChecker.reportErrorMessage(returnValue.Begin(), "Could not append to known data")
 				}
 
+				constraintChecker.assumeLiteralValue(returnValue, functionStack.outputNames[index], functionStack.outputTypes[index])
+
 			} else if index == len(functionStack.outputNames) {
 				constraintChecker.reportErrorMessage(returnValue.Begin(), "Too many return arguments")
 			}
 		}
 
-		result, err := state.checkOrGroup(postCondition)
+		result, err := state.checkOrGroup(substituted)
 
 		if err != nil {
-			constraintChecker.reportErrorMessage(ret.Begin(), err.Error())
+			constraintChecker.reportErrorMessage(at, err.Error())
 		} else if len(result) > 0 {
 			constraintChecker.reportError(parser.CreateErrorWithMultipleLocations(
-				ret.Begin(),
+				at,
 				"Could not verify post conditions",
 				constraintChecker.formatErrorWithConstraints("With precondition at\n", result),
 			))
@@ -194,32 +1169,171 @@ func (constraintChecker *ConstraintChecker) VisitReturn(ret *parser.ReturnStatem
 	}
 }
 
-func (constraintChecker *ConstraintChecker) formatErrorWithConstraints(lineMessage string, conditions []*boundschecking.SumGroup) []parser.ParseError {
-	var sourceErrors []parser.ParseError = nil
-	var topFrame = constraintChecker.peekFunctionStack()
-	var alreadyFormatted = make(map[uint32]bool)
+func (constraintChecker *ConstraintChecker) VisitVarDef(varDef *parser.VariableDefinition) {
+	var normalizerState = constraintChecker.normalizerState
+	var sumGroup *boundschecking.SumGroup = nil
+	var value boundschecking.NormalizedNode = nil
+	var fits = false
 
-	if topFrame != nil {
-		for _, condition := range conditions {
-			var id = condition.GetUniqueId()
+	if varDef.Value != nil {
+		varDef.Value.Accept(constraintChecker)
+		sumGroup, _ = constraintChecker.normalizeValue(varDef.Value)
+		value, _ = normalizerState.NormalizeToNode(varDef.Value)
+		fits = constraintChecker.checkFits(varDef.Value, varDef.Type)
 
-			_, ok := alreadyFormatted[id]
+		if !fits {
+			sumGroup = nil
+		}
+	}
 
-			if !ok {
-				alreadyFormatted[id] = true
+	normalizerState.UseIdentifierMapping(varDef.Name.Value, varDef.UniqueId)
+	constraintChecker.declarationTypes[varDef.UniqueId] = varDef.Type
+	var reference = normalizerState.CreateVariableReference(varDef.Name.Value, varDef.UniqueId)
+	constraintChecker.assumeTypeFacts(reference, varDef.Type)
 
-				expression, ok := topFrame.expressionMapping[id]
+	if sumGroup != nil {
+		constraintChecker.assumeEquality(varDef.Begin(), sumGroup, reference)
+		constraintChecker.assumeLiteralValue(varDef.Value, reference, varDef.Type)
+	}
 
-				if ok {
-					sourceErrors = append(sourceErrors, parser.CreateError(expression.Begin(), lineMessage))
-				}
-			}
+	_, isStructure := varDef.Type.(*parser.StructureTypeType)
+
+	if fits && isStructure && value != nil && constraintChecker.peekState() != nil {
+		_, isCall := varDef.Value.(*parser.CallExpression)
+		_, isLiteral := varDef.Value.(*parser.StructureExpression)
+		constraintChecker.assumeSameFields(value, reference, varDef.Type, nil, isCall || isLiteral, 0)
+	}
+}
+
+func (constraintChecker *ConstraintChecker) VisitDestructure(destructure *parser.DestructuringDefinition) {
+	destructure.Value.Accept(constraintChecker)
+
+	var normalizerState = constraintChecker.normalizerState
+	value, err := normalizerState.NormalizeToNode(destructure.Value)
+	asStructure, ok := destructure.Value.GetType().(*parser.StructureTypeType)
+	_, isCall := destructure.Value.(*parser.CallExpression)
+	_, isLiteral := destructure.Value.(*parser.StructureExpression)
+
+	for index, name := range destructure.Names {
+		normalizerState.UseIdentifierMapping(name.Value, destructure.UniqueIds[index])
+		constraintChecker.declarationTypes[destructure.UniqueIds[index]] = destructure.Types[index]
+		var reference = normalizerState.CreateVariableReference(name.Value, destructure.UniqueIds[index])
+
+		if err == nil && ok && index < len(asStructure.Entries) && constraintChecker.peekState() != nil {
+			var field = normalizerState.CreatePropertyReference(value, asStructure.Entries[index].Name, 0)
+			constraintChecker.assumeSameFields(field, reference, destructure.Types[index], nil, isCall || isLiteral, 0)
+		}
+
+		constraintChecker.assumeTypeFacts(reference, destructure.Types[index])
+	}
+}
+
+func (constraintChecker *ConstraintChecker) VisitAssignment(assignment *parser.AssignmentStatement) {
+	assignment.Value.Accept(constraintChecker)
+
+	var fits = constraintChecker.checkFits(assignment.Value, assignment.Target.GetType())
+
+	asProperty, ok := assignment.Target.(*parser.PropertyExpression)
+
+	if ok {
+		constraintChecker.assignField(assignment, asProperty, fits)
+		return
+	}
+
+	asIdentifier, ok := assignment.Target.(*parser.Identifier)
+
+	if !ok {
+		assignment.Target.Accept(constraintChecker)
+		return
+	}
+
+	sumGroup, _ := constraintChecker.normalizeValue(assignment.Value)
+	var reference = constraintChecker.normalizerState.ReassignIdentifier(asIdentifier.Token.Value)
+	source, _ := constraintChecker.normalizerState.GetIdentifierSource(asIdentifier.Token.Value)
+	constraintChecker.peekState().modifiedDeclarations[source.Declaration] = true
+	constraintChecker.assumeTypeFacts(reference, asIdentifier.GetType())
+
+	if !fits {
+		sumGroup = nil
+	}
+
+	if sumGroup != nil {
+		constraintChecker.assumeEquality(assignment.Begin(), sumGroup, reference)
+		constraintChecker.assumeLiteralValue(assignment.Value, reference, asIdentifier.GetType())
+	}
+}
+
+// An assertion that holds is known afterwards. One that can't be verified is
+// reported once and then assumed so it doesn't cause more errors
+func (constraintChecker *ConstraintChecker) VisitAssert(assert *parser.AssertStatement) {
+	var state = constraintChecker.peekState()
+
+	assert.Expression.Accept(constraintChecker)
+
+	if state == nil {
+		return
+	}
+
+	if assert.IsAssumption {
+		constraintChecker.reportError(parser.CreateSpanWarning(assert.Expression.Begin(), assert.Expression.End(), "Assumed without verifying"))
+	} else {
+		var rules = constraintChecker.normalizerState.NormalizeToOrGroup(assert.Expression)
+		result, err := state.checkOrGroup(rules)
+
+		if err != nil {
+			constraintChecker.reportErrorMessage(assert.Expression.Begin(), err.Error())
+		} else if len(result) > 0 || (len(rules.AndGroups) == 0 && len(state.knownConstraints) > 0) {
+			constraintChecker.reportError(parser.CreateSpanError(assert.Expression.Begin(), assert.Expression.End(), "Could not verify assertion"))
+		}
+	}
+
+	constraintChecker.assumeCondition(assert.Expression, true)
+}
+
+// Every disjunct of a state is dropped once it contradicts itself so the
+// statement is unreachable when none are left
+func (constraintChecker *ConstraintChecker) VisitUnreachable(unreachable *parser.UnreachableStatement) {
+	var state = constraintChecker.peekState()
+
+	if state == nil {
+		return
+	}
+
+	if state.isReachable() {
+		constraintChecker.reportError(parser.CreateSpanError(unreachable.Begin(), unreachable.End(), "Could not verify that the statement is unreachable"))
+	}
+
+	state.knownConstraints = nil
+}
+
+func formatErrorWithConstraints(expressionMapping map[uint32]parser.Expression, lineMessage string, conditions []*boundschecking.SumGroup) []parser.ParseError {
+	var sourceErrors []parser.ParseError = nil
+	var alreadyFormatted = make(map[tokenizer.SourceLocation]bool)
+
+	// clauses that fail in several disjuncts or split into several rules
+	// are only listed once
+	for _, condition := range conditions {
+		expression, ok := expressionMapping[condition.GetUniqueId()]
+
+		if ok && !alreadyFormatted[expression.Begin()] {
+			alreadyFormatted[expression.Begin()] = true
+			sourceErrors = append(sourceErrors, parser.CreateError(expression.Begin(), lineMessage))
 		}
 	}
 
 	return sourceErrors
 }
 
+func (constraintChecker *ConstraintChecker) formatErrorWithConstraints(lineMessage string, conditions []*boundschecking.SumGroup) []parser.ParseError {
+	var topFrame = constraintChecker.peekFunctionStack()
+
+	if topFrame == nil {
+		return nil
+	}
+
+	return formatErrorWithConstraints(topFrame.expressionMapping, lineMessage, conditions)
+}
+
 func (constraintChecker *ConstraintChecker) VisitNamedType(namedType *parser.NamedType) {
 
 }
@@ -232,6 +1346,10 @@ func (constraintChecker *ConstraintChecker) VisitFunctionType(fn *parser.Functio
 
 }
 
+func (constraintChecker *ConstraintChecker) VisitArrayType(array *parser.ArrayType) {
+
+}
+
 func (constraintChecker *ConstraintChecker) VisitWhereType(where *parser.WhereType) {
 	where.TypeExp.Accept(constraintChecker)
 
@@ -265,6 +1383,14 @@ func (constraintChecker *ConstraintChecker) VisitWhereType(where *parser.WhereTy
 	}
 }
 
+func (constraintChecker *ConstraintChecker) VisitUnionType(union *parser.UnionType) {
+	for _, variant := range union.Variants {
+		if variant.TypeExp != nil {
+			variant.TypeExp.Accept(constraintChecker)
+		}
+	}
+}
+
 func (constraintChecker *ConstraintChecker) VisitTypeDef(typeDef *parser.TypeDefinition) {
 	typeDef.TypeExp.Accept(constraintChecker)
 }
@@ -273,6 +1399,26 @@ func (constraintChecker *ConstraintChecker) VisitFnDef(fnDef *parser.FunctionDef
 	fnDef.Function.Accept(constraintChecker)
 }
 
+// The value of a constant has to fit in its type and satisfy its where
+// expression
+func (constraintChecker *ConstraintChecker) VisitConst(constDef *parser.ConstantDefinition) {
+	var state = constraintChecker.createState()
+	constDef.Value.Accept(constraintChecker)
+	constraintChecker.checkFits(constDef.Value, constDef.Type)
+
+	if constDef.WhereExp != nil {
+		var normalizerState = constraintChecker.normalizerState
+		var rules = normalizerState.NormalizeToOrGroup(constDef.WhereExp)
+		result, err := state.checkOrGroup(rules)
+
+		if err != nil || len(result) > 0 {
+			constraintChecker.reportErrorMessage(constDef.WhereExp.Begin(), "Could not verify the where expression of constant '"+constDef.Name.Value+"'")
+		}
+	}
+
+	constraintChecker.popState()
+}
+
 func (constraintChecker *ConstraintChecker) VisitFile(fileDef *parser.FileDefinition) {
 	for _, definition := range fileDef.Definitions {
 		definition.Accept(constraintChecker)
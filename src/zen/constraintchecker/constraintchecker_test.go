@@ -0,0 +1,1180 @@
+package constraintchecker
+
+import (
+	"strings"
+	"testing"
+	"zen/parser"
+	"zen/source"
+	"zen/test"
+	"zen/typechecker"
+)
+
+func checkSource(t *testing.T, sourceString string) []parser.ParseError {
+	fileDef, errors := parser.Parse(source.SourceFromString(sourceString))
+
+	if len(errors) != 0 {
+		for _, err := range errors {
+			t.Log(parser.FormatError(err))
+		}
+		t.Fatal("Unexpected parse errors")
+	}
+
+	errors = typechecker.CheckTypes(fileDef)
+
+	if len(errors) != 0 {
+		for _, err := range errors {
+			t.Log(parser.FormatError(err))
+		}
+		t.Fatal("Unexpected type errors")
+	}
+
+	return CheckConstraints(fileDef)
+}
+
+func TestVariableFacts(t *testing.T) {
+	var errors = checkSource(t, `
+		func Inc[a: i32] => [result: i32] where a < 1000 && result > a {
+			let b = a + 1
+			return b
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Declarations should be known facts")
+
+	errors = checkSource(t, `
+		func Inc[a: i32] => [result: i32] where a < 1000 && result > a {
+			var b = a + 1
+			b = a
+			return b
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Reassignment should replace old facts")
+
+	errors = checkSource(t, `
+		func Inc[a: i32] => [result: i32] where a < 1000 && result > a {
+			var b = a
+			b = b + 1
+			return b
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Assignment can refer to the previous value")
+
+	errors = checkSource(t, `
+		func Inc[a: i32] => [result: i32] where a < 1000 && result > a {
+			var b = a + 1
+			if (a > 0) {
+				b = a
+			}
+			return b
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Assignment inside a branch should invalidate facts")
+
+	errors = checkSource(t, `
+		func Inc[a: i32] => [result: i32] where a < 1000 && result > a {
+			let b = a + 1
+			if (a > 0) {
+				let b = a
+			}
+			return b
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Shadowing inside a branch should not invalidate facts")
+}
+
+func TestCallContracts(t *testing.T) {
+	var contracts = `
+		func Div[a: i32, b: i32] => [result: i32] where b > 0 && a >= 0 && result <= a {
+			return 0
+		}
+
+		func Min[a: i32, b: i32] => [result: i32] where result <= a && result <= b {
+			if (a < b) {
+				return a
+			} else {
+				return b
+			}
+		}
+	`
+
+	var errors = checkSource(t, contracts+`
+		func Test[x: i32] => [result: i32] where result <= x {
+			return Min(x, 10)
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Postconditions should be known after a call")
+
+	errors = checkSource(t, contracts+`
+		func Test[x: i32] => [result: i32] {
+			return Div(10, x)
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Unproven preconditions should be reported")
+
+	errors = checkSource(t, `
+		func Exactly[a: i32] => [result: i32] where a == 5 {
+			return a
+		}
+
+		func Test[x: i32] => [result: i32] {
+			return Exactly(x)
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Unproven equalities should be reported once")
+	test.Assert(
+		t,
+		strings.Count(parser.FormatError(errors[0]), "With precondition at") == 1,
+		"Each failed precondition should be listed once",
+	)
+
+	errors = checkSource(t, contracts+`
+		func Test[x: i32] => [result: i32] {
+			if (x > 0) {
+				return Div(10, x)
+			}
+			return 0
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Preconditions can be proven by the caller")
+
+	errors = checkSource(t, contracts+`
+		func Test[x: i32] => [result: i32] where result <= 10 {
+			let a = Min(x, 10)
+			let b = Min(x, 20)
+			return a
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Separate calls should not contradict each other")
+}
+
+func TestArrayBounds(t *testing.T) {
+	var errors = checkSource(t, `
+		func Get[data: []i32, i: i32] => [result: i32] {
+			return data[i]
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Unchecked indices should be reported")
+
+	errors = checkSource(t, `
+		func Get[data: []i32, i: i32] => [result: i32] where 0 <= i && i < Len(data) {
+			return data[i]
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Preconditions can prove an index")
+
+	errors = checkSource(t, `
+		func Last[data: []i32] => [result: i32] {
+			if (Len(data) > 0) {
+				return data[data.length - 1]
+			}
+			return 0
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Len and length should be the same value")
+
+	errors = checkSource(t, `
+		func Last[data: []i32] => [result: i32] {
+			if (Len(data) > 0) {
+				return data[Len(data)]
+			}
+			return 0
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "The length itself is out of bounds")
+}
+
+func TestIntegerRanges(t *testing.T) {
+	var errors = checkSource(t, `
+		func Byte[] => [] {
+			let x: u8 = 300
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "300 does not fit in a u8")
+
+	errors = checkSource(t, `
+		func Byte[] => [] {
+			let x: u8 = 1
+			var y: i8 = -128
+			y = 127
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Constants in range should fit")
+
+	errors = checkSource(t, `
+		func Byte[x: u8] => [r: i32] where r >= 0 && r <= 255 {
+			return x
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Parameters should be within the range of their type")
+
+	errors = checkSource(t, `
+		func Narrow[x: i32] => [r: u16] {
+			return x
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Narrowing needs to be proven")
+
+	errors = checkSource(t, `
+		func Narrow[x: i32] => [r: u16] where x >= 0 && x < 1000 {
+			return x
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Preconditions can prove a narrowing")
+
+	errors = checkSource(t, `
+		func Low[x: u8] => [r: u8] {
+			return x
+		}
+
+		func Call[x: i32] => [r: u8] {
+			return Low(x)
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Arguments should fit in the parameter type")
+}
+
+func TestWideIntegerRanges(t *testing.T) {
+	var errors = checkSource(t, `
+		func Same[a: u64] => [r: u64] {
+			return a + 0
+		}
+
+		func Sum[a: i64, b: i64] => [r: i64] where a < 100 && a > -100 && b < 100 && b > -100 {
+			return a + b
+		}
+
+		func Positive[a: u64] => [r: u64] where r >= 0 {
+			return a
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "64 bit values should be within the range of their type")
+
+	errors = checkSource(t, `
+		func Inc[a: i64] => [r: i64] {
+			return a + 1
+		}
+
+		func Dec[a: u64] => [r: u64] {
+			return a - 1
+		}
+	`)
+	test.Assert(t, len(errors) == 2, "64 bit arithmetic can still overflow")
+}
+
+func TestWhereTypes(t *testing.T) {
+	var errors = checkSource(t, `
+		type Flag [v: i32] where v == 0
+	`)
+	test.Assert(t, len(errors) == 0, "A field equal to zero should not be a contradiction")
+
+	errors = checkSource(t, `
+		type Pair [x: i32, v: i32] where x > 0 && v == 0
+	`)
+	test.Assert(t, len(errors) == 0, "A conjunction with a field equal to zero should not be a contradiction")
+
+	errors = checkSource(t, `
+		type Small [v: u8] where v <= 0
+	`)
+	test.Assert(t, len(errors) == 0, "An upper bound of zero should not be a contradiction")
+
+	errors = checkSource(t, `
+		type Empty [v: i32] where v > 0 && v < 0
+	`)
+	test.Assert(t, len(errors) == 1, "Contradicting where expressions should be reported")
+}
+
+func TestOverflow(t *testing.T) {
+	var errors = checkSource(t, `
+		func Add[a: i32, b: i32] => [r: i32] {
+			return a + b
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Unbounded addition can overflow")
+	test.Assert(t, len(errors) == 1 && strings.HasPrefix(parser.FormatError(errors[0]), "possible overflow of i32 addition\n"), "Overflow should name the operation")
+
+	errors = checkSource(t, `
+		func Add[a: i32, b: i32] => [r: i32] where a >= 0 && a < 1000 && b > -1000 && b < 0 {
+			return a + b - 1
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Bounded operands should not overflow")
+
+	errors = checkSource(t, `
+		func Add[a: u8, b: u8] => [r: u8] {
+			return a - b
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Unsigned subtraction can overflow")
+
+	errors = checkSource(t, `
+		func Add[a: i32, b: i32] => [r: i32] {
+			return a +% b -% a *% b
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Wrapping operators should not be checked")
+
+	errors = checkSource(t, `
+		func Div[a: i32, b: i32] => [r: i32] where b > 0 {
+			return a / b
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Dividing by a positive number can't overflow")
+
+	errors = checkSource(t, `
+		func Div[a: i32, b: i32] => [r: i32] where b < 0 {
+			return a / b
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Dividing the smallest i32 by -1 overflows")
+}
+
+func TestDivideByZero(t *testing.T) {
+	var errors = checkSource(t, `
+		func Div[a: i32, b: i32] => [r: i32] where b >= 0 {
+			return a / b
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Divisor could be zero")
+
+	errors = checkSource(t, `
+		func Div[a: i32, b: i32] => [r: i32] {
+			if (b > 0) {
+				return a / b
+			} else {
+				return a % b
+			}
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Only the else branch can divide by zero")
+	test.Assert(
+		t,
+		len(errors) == 1 && strings.HasPrefix(parser.FormatError(errors[0]), "Could not verify divisor is not zero\nWith negated branch condition at\n"),
+		"Divide by zero should list the branch conditions",
+	)
+
+	errors = checkSource(t, `
+		func Div[a: i32, b: i32] => [r: i32] where b > 0 {
+			return a / b
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Preconditions can prove a divisor")
+
+	errors = checkSource(t, `
+		func Div[a: i32, b: i32] => [r: i32] where b > -5 && b < 0 {
+			return a / (b - 1) + 2
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Negative divisors aren't zero but the sum could overflow")
+}
+
+func TestFieldInvariants(t *testing.T) {
+	var counter = `
+		type Counter [
+			mut count: u32,
+			mut limit: u32,
+		] where count <= limit
+
+		func Size[counter: Counter] => [r: u32] where r <= counter.limit {
+			return counter.count
+		}
+	`
+
+	var errors = checkSource(t, counter+`
+		func Increment[counter: Counter] => [] {
+			if (counter.count < counter.limit) {
+				counter.count = counter.count + 1
+			}
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Invariants restored in a branch should hold after it")
+
+	errors = checkSource(t, counter+`
+		func Reset[counter: Counter] => [] {
+			counter.limit = 0
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Invariants should hold at function exit")
+	test.Assert(
+		t,
+		len(errors) == 1 && strings.HasPrefix(parser.FormatError(errors[0]), "Could not verify invariant of 'counter' holds at function exit\nWith invariant\n"),
+		"Broken invariants should name the invariant",
+	)
+
+	errors = checkSource(t, counter+`
+		func Reset[counter: Counter] => [r: u32] {
+			counter.limit = 0
+			let size = Size(counter)
+			counter.count = 0
+			return size
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Invariants should hold before calls")
+
+	errors = checkSource(t, counter+`
+		func Reset[counter: Counter] => [r: u32] {
+			counter.count = 0
+			counter.limit = 0
+			return Size(counter)
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Invariants only need to hold at call boundaries")
+
+	errors = checkSource(t, counter+`
+		func Grow[counter: Counter] => [r: u32] where r > 0 {
+			counter.count = 1
+			counter.limit = 2
+			Size(counter)
+			return counter.limit
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Calls can assign mutable fields")
+}
+
+func TestOldAndPost(t *testing.T) {
+	var counter = `
+		type Counter [
+			mut count: u32,
+		]
+
+		func Increment[counter: Counter] => [] where counter.count < 100 && post counter.count == old(counter.count) + 1 {
+			counter.count = counter.count + 1
+		}
+	`
+
+	var errors = checkSource(t, counter)
+	test.Assert(t, len(errors) == 0, "Post clauses should be checked against the assigned fields")
+
+	errors = checkSource(t, `
+		type Counter [
+			mut count: u32,
+		]
+
+		func Increment[counter: Counter] => [] where counter.count < 100 && post counter.count == old(counter.count) + 2 {
+			counter.count = counter.count + 1
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Post clauses that don't hold should be reported")
+
+	errors = checkSource(t, counter+`
+		func IncrementTwice[counter: Counter] => [] where counter.count < 50 && post counter.count == old(counter.count) + 2 {
+			Increment(counter)
+			Increment(counter)
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Callers should know the post clauses of a call")
+
+	errors = checkSource(t, counter+`
+		func IncrementTwice[counter: Counter] => [] where counter.count < 100 {
+			Increment(counter)
+			Increment(counter)
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Preconditions should use the values from after earlier calls")
+
+	errors = checkSource(t, counter+`
+		func IncrementOnce[counter: Counter] => [r: u32] where counter.count < 10 && r > 0 {
+			Increment(counter)
+			return counter.count
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Post clauses should be known after a call")
+}
+
+func TestWhileLoops(t *testing.T) {
+	var errors = checkSource(t, `
+		func Count[n: u32] => [r: u32] where n < 1000 && r == n {
+			var i: u32 = 0
+			while (i < n) invariant i <= n decreases n - i {
+				i = i + 1
+			}
+			return i
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "The invariant and negated condition should hold after the loop")
+
+	errors = checkSource(t, `
+		func Count[n: u32] => [r: u32] where n < 1000 {
+			var i: u32 = 0
+			while (i < n) invariant i < n decreases n - i {
+				i = i + 1
+			}
+			return i
+		}
+	`)
+	test.Assert(t, len(errors) == 2, "Invariants should hold on entry and be preserved")
+
+	errors = checkSource(t, `
+		func Count[n: u32] => [r: u32] where n < 1000 {
+			var i: u32 = 0
+			while (i < n) invariant i <= n decreases n - i {
+				i = i
+			}
+			return i
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Loop measures should decrease")
+
+	errors = checkSource(t, `
+		func Count[n: i32] => [r: i32] where n < 1000 {
+			var i: i32 = n
+			while (i < 1000) decreases i {
+				i = i + 1
+			}
+			return i
+		}
+	`)
+	test.Assert(t, len(errors) == 2, "Loop measures should not be negative")
+
+	errors = checkSource(t, `
+		func Sum[data: []i32, n: u32] => [r: i32] where n == Len(data) {
+			var i: u32 = 0
+			var total: i32 = 0
+			while (i < n) invariant i <= n decreases n - i {
+				total = data[i] +% total
+				i = i + 1
+			}
+			return total
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Facts from the condition should be known in the body")
+
+	errors = checkSource(t, `
+		func Count[n: u32] => [r: u32] where r == 0 {
+			var i: u32 = 0
+			while (i < n) {
+				i = i + 1
+			}
+			return i
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Values assigned in the loop should be forgotten after it")
+}
+
+func TestForeachLoops(t *testing.T) {
+	var errors = checkSource(t, `
+		func Sum[values: []i32] => [r: i32] {
+			var total: i32 = 0
+			foreach value, index in values {
+				total = values[index] +% total
+			}
+			return total
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Loop indices should be within the array")
+
+	errors = checkSource(t, `
+		func Sum[values: []i32] => [r: i32] {
+			var total: i32 = 0
+			foreach value, index in values {
+				total = values[index + 1] +% total
+			}
+			return total
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Only the loop index should be known to be within the array")
+
+	errors = checkSource(t, `
+		func Sum[values: []i32] => [r: i32] where r == 0 {
+			var total: i32 = 0
+			foreach total +%= in values
+			return total
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Values assigned in the loop should be forgotten after it")
+
+	errors = checkSource(t, `
+		func Sum[values: []i32] => [r: i32] {
+			var total: i32 = 0
+			foreach total += in values
+			return total
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Accumulators should be checked like the loops they stand for")
+}
+
+func TestFunctionContracts(t *testing.T) {
+	var step = `
+		type Step [a: u8] => [r: u8] where a < 100 && r > a
+
+		func Apply[step: Step, x: u8] => [r: u8] where x < 50 && r > x {
+			return step(x)
+		}
+	`
+
+	var errors = checkSource(t, step+`
+		func Increment[x: u8] => [r: u8] where x < 50 && r > x {
+			return Apply([a: u8] => [r: u8] where a < 200 && r == a + 1 { return a + 1 }, x)
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Lambdas with weaker preconditions and stronger postconditions should fit")
+
+	errors = checkSource(t, step+`
+		func Increment[x: u8] => [r: u8] where x < 50 {
+			return Apply([a: u8] => [r: u8] where a < 200 && r >= a { return a }, x)
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Lambdas should promise the postconditions of the type")
+
+	errors = checkSource(t, step+`
+		func Increment[x: u8] => [r: u8] where x < 50 {
+			return Apply([a: u8] => [r: u8] where a < 10 && r == a + 1 { return a + 1 }, x)
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Lambdas should accept the inputs of the type")
+
+	errors = checkSource(t, step+`
+		func Once[step: Step, x: u8] => [r: u8] where x < 50 && r >= x + 1 {
+			return step(x)
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Calls through function values should use their contract")
+
+	errors = checkSource(t, step+`
+		func Once[step: Step, x: u8] => [r: u8] {
+			return step(x)
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Calls through function values should check preconditions")
+
+	errors = checkSource(t, `
+		func Increment[x: u8] => [r: u8] where x < 50 && r > x {
+			let offset: u8 = 10
+			let step = [a: u8] => [r: u8] where a < 100 && r == a + offset { return a + offset }
+			return step(x)
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Lambdas should know about the values they capture")
+}
+
+func TestStructureLiterals(t *testing.T) {
+	var rangeType = `
+		type Range [
+			Min: i32,
+			Max: i32,
+		] where Min <= Max
+	`
+
+	var errors = checkSource(t, rangeType+`
+		func MakeRange[a: i32, b: i32] => [result: Range] {
+			if (a < b) {
+				return [a, b]
+			} else {
+				return [b, a]
+			}
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Literals should satisfy the where expression of their type")
+
+	errors = checkSource(t, rangeType+`
+		func Empty[] => [] {
+			var range: Range = [2, 1]
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Literals should be checked against the type of their variable")
+	test.Assert(
+		t,
+		len(errors) == 1 && strings.HasPrefix(parser.FormatError(errors[0]), "Could not verify structure satisfies the where expression of its type\nWith invariant\n"),
+		"Literals that break an invariant should name the invariant",
+	)
+
+	errors = checkSource(t, rangeType+`
+		func MakeRange[a: i32, b: i32] => [result: Range] where a <= b {
+			return [Max: b, Min: a]
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Named entries should be matched to fields by name")
+
+	errors = checkSource(t, rangeType+`
+		func Clamp[range: Range, x: i32] => [r: i32] where range.Min <= x && x <= range.Max {
+			return x
+		}
+
+		func Test[a: i32] => [r: i32] where a < 100 {
+			let range: Range = [a, a + 1]
+			let x = Clamp(range, a + 1)
+			return Clamp([a, a + 2], a + 2)
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "The entries of a literal should be known for the value it is given to")
+
+	errors = checkSource(t, `
+		type Small [
+			value: u8,
+		]
+
+		func Make[a: u32] => [result: Small] {
+			return [a]
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Entries of a literal should fit the type of their field")
+}
+
+func TestDestructuring(t *testing.T) {
+	var bisect = `
+		type Range [
+			Min: i32,
+			Max: i32,
+		] where Min <= Max
+
+		func Bisect[range: Range, at: i32] => [a: Range, b: Range]
+			where range.Min <= at && at <= range.Max && a.Max == at && b.Min == at
+		{
+			return [range.Min, at], [at, range.Max]
+		}
+	`
+
+	var errors = checkSource(t, bisect+`
+		func Low[range: Range, at: i32] => [r: i32] where range.Min <= at && at <= range.Max && r == at {
+			let [lo, hi] = Bisect(range, at)
+			return lo.Max
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Postconditions should be known for destructured outputs")
+
+	errors = checkSource(t, bisect+`
+		func High[range: Range, at: i32] => [r: i32] where range.Min <= at && at <= range.Max && r == at {
+			return Bisect(range, at).b.Min
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Postconditions should be known for outputs accessed by name")
+
+	errors = checkSource(t, bisect+`
+		func Low[range: Range, at: i32] => [r: i32] where range.Min <= at && at <= range.Max && r == range.Min {
+			let [lo, hi] = Bisect(range, at)
+			return hi.Min
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Destructured outputs should only have the facts of the callee")
+
+	errors = checkSource(t, bisect+`
+		func Low[range: Range, at: i32] => [r: i32] where range.Min <= at && at <= range.Max && r <= at {
+			let [lo, hi] = Bisect(range, at)
+			let [min, max] = lo
+			return min
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Destructured structures should keep the facts about their fields")
+
+	errors = checkSource(t, bisect+`
+		func Low[range: Range, at: i32] => [r: i32] where range.Min <= at && at <= range.Max && r == at {
+			let both = Bisect(range, at)
+			return both.a.Max
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Variables holding call results should keep the postconditions of the fields")
+
+	errors = checkSource(t, bisect+`
+		func Low[range: Range] => [r: i32] where r <= range.Max {
+			let copy = range
+			return copy.Min
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Variables copied from other structures should keep the facts about their fields")
+
+	errors = checkSource(t, bisect+`
+		func Low[range: Range, at: i32] => [r: i32] where range.Min <= at && at <= range.Max && r == range.Min {
+			let both = Bisect(range, at)
+			return both.b.Min
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Variables holding call results should only have the facts of the callee")
+}
+
+func TestUnions(t *testing.T) {
+	var shape = `
+		type Shape = Circle[r: i32] where r > 0 && r < 1000 | Rect[w: i32, h: i32] where w >= 0 && h >= 0 && w < 100 && h < 100 | Empty
+	`
+
+	var errors = checkSource(t, shape+`
+		func Size[shape: Shape] => [r: i32] where r >= 0 {
+			match shape {
+				Circle => { return shape.r + shape.r }
+				Rect => { return shape.w + shape.h }
+				Empty => { return 0 }
+			}
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Arms should know the where expression of their variant")
+
+	errors = checkSource(t, shape+`
+		func Size[shape: Shape] => [r: i32] where r > 0 {
+			match shape {
+				Circle => { return shape.r }
+				Rect => { return shape.w }
+				Empty => { return 1 }
+			}
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Arms should only know the where expression of their own variant")
+
+	errors = checkSource(t, shape+`
+		func Make[x: i32] => [r: Shape] where x < 10 {
+			if (x > 0) {
+				return Circle(x)
+			}
+			return Circle(x + 1)
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Constructing a variant should check its where expression")
+
+	errors = checkSource(t, shape+`
+		func Size[shape: Shape] => [r: i32] where r >= 0 {
+			let size = match shape {
+				Circle => shape.r + shape.r,
+				Rect => shape.w + shape.h,
+				Empty => 0,
+			}
+			return size
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "A match expression should have the value of the arm that was taken")
+
+	errors = checkSource(t, shape+`
+		func Size[shape: Shape] => [r: i32] where r > 0 {
+			let size = match shape { Circle => shape.r, Rect => shape.w, Empty => 1 }
+			return size
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Each arm of a match expression should be checked")
+}
+
+func TestBooleans(t *testing.T) {
+	var errors = checkSource(t, `
+		func Check[a: i32, b: i32] => [r: i32] where r < b {
+			let ok = a < b
+			if (ok) {
+				return a
+			}
+			return a - a + b - b + a
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "A stored comparison should prove the same facts as the comparison")
+
+	errors = checkSource(t, `
+		func Check[a: i32, b: i32] => [r: i32] where a < 100 && b > 0 && b < 100 && r < b {
+			let ok = a >= b
+			if (!ok) {
+				return a
+			} else {
+				return b - 1
+			}
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "A negated boolean should prove the negated comparison")
+
+	errors = checkSource(t, `
+		func Check[ok: bool, a: i32] => [r: i32] where r < 5 {
+			if (ok && a < 5) {
+				return a
+			}
+			return 0
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Boolean parameters should be usable in conditions")
+
+	errors = checkSource(t, `
+		func Valid[ok: bool, a: i32] => [r: i32] where ok && r == a {
+			return a
+		}
+
+		func Use[a: i32] => [r: i32] where a < 10 && r < 10 {
+			return Valid(a < 10, a)
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Comparisons given as arguments should be linked to the boolean")
+
+	errors = checkSource(t, `
+		type Either [on: bool, v: i32] where on || v == 0
+		type Implies [on: bool, v: i32] where !on || v == 0
+		type Both [on: bool, v: i32] where on && v == 0
+		type Set [on: bool, v: i32] where on
+	`)
+	test.Assert(t, len(errors) == 0, "Boolean fields should be usable in where expressions")
+
+	errors = checkSource(t, `
+		type Never [on: bool, v: i32] where on && !on
+	`)
+	test.Assert(t, len(errors) == 1, "Contradicting boolean fields should be reported")
+}
+
+func TestConstants(t *testing.T) {
+	var errors = checkSource(t, `
+		const MaxPlayers = 16
+		const MaxTeams: u8 = MaxPlayers * 2 where MaxTeams > MaxPlayers
+
+		type Lobby [players: i32] where players >= 0 && players <= MaxPlayers
+
+		func Join[lobby: Lobby] => [r: Lobby] where lobby.players < MaxPlayers {
+			return [lobby.players + 1]
+		}
+
+		func Scale[x: i32] => [r: i32] where x >= 0 && x < 100 && r <= MaxTeams * 99 {
+			return x * MaxTeams
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Constants should be folded into linear facts")
+
+	errors = checkSource(t, `
+		const Large: u8 = 300
+		const Small = 5 where Small > 10
+	`)
+	test.Assert(t, len(errors) == 2, "Constants should fit their type and satisfy their where expression")
+
+	errors = checkSource(t, `
+		const MaxPlayers = 16
+		const Half = MaxPlayers / 2 where Half == 8
+		const Rest = -MaxPlayers % 5 where Rest == -1
+
+		func Split[x: i32] => [r: i32] where x >= 0 && x < Half && r < MaxPlayers {
+			return x + Half
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Division of constants should be folded")
+}
+
+func TestEarlyReturns(t *testing.T) {
+	var errors = checkSource(t, `
+		func Clamp[x: i32] => [r: i32] where r >= 0 && r < 100 {
+			if (x < 0) {
+				return 0
+			} else if (x >= 100) {
+				return 99
+			}
+			return x
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Statements after a branch that returns should know the negated condition")
+
+	errors = checkSource(t, `
+		func Offset[x: i32] => [r: i32] where x >= 0 && x < 10 && r > 5 {
+			var y = 0
+			if (x > 100) {
+				return 6
+			} else {
+				y = x + 10
+			}
+			return y
+		}
+	`)
+	test.Assert(t, len(errors)-countWarnings(errors) == 0, "Facts from the only branch that falls through should carry over")
+
+	errors = checkSource(t, `
+		func Shadow[x: i32] => [r: i32] where x >= 0 && x < 10 && r < 10 {
+			var y = x
+			if (x > 5) {
+				return 0
+			} else {
+				y = 100
+				let y = 3
+			}
+			return y
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Variables declared in a branch should go out of scope after it")
+
+	errors = checkSource(t, `
+		func Positive[x: i32] => [r: i32] where r >= 0 {
+			if (x < 0) {
+				let a = 1
+			}
+			return x
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Branches that fall through should not tell the rest of the body anything")
+}
+
+func TestJoinPoints(t *testing.T) {
+	var errors = checkSource(t, `
+		func Min[a: i32, b: i32] => [r: i32] where r <= a && r <= b {
+			var m = 0
+			if (a < b) {
+				m = a
+			} else {
+				m = b
+			}
+			return m
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Values assigned in both branches should be known after the if statement")
+
+	errors = checkSource(t, `
+		func Positive[x: i32] => [r: i32] where r >= 0 {
+			var y = x
+			if (y < 0) {
+				y = 0
+			}
+			return y
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "An if statement without an else should join with the negated condition")
+
+	errors = checkSource(t, `
+		func Abs[x: i32] => [r: i32] where r >= 0 {
+			var negative = x < 0
+			if (x > 100) {
+				negative = x < 50
+			}
+			if (negative) {
+				return 0
+			}
+			return x
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Booleans assigned in a branch should be joined")
+
+	errors = checkSource(t, `
+		func Abs[x: i32] => [r: i32] where r >= 0 {
+			var negative = x < 0
+			if (x > 100) {
+				negative = x < 50
+			}
+			if (negative) {
+				return x
+			}
+			return 0
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Joining booleans should not lose either value")
+
+	errors = checkSource(t, `
+		func Max[a: i32, b: i32] => [r: i32] where r >= a && r >= b {
+			var m = 0
+			if (a < b) {
+				m = a
+			} else {
+				m = b
+			}
+			return m
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "The join should keep the value of each branch apart")
+}
+
+func TestAssertions(t *testing.T) {
+	var errors = checkSource(t, `
+		func Check[a: i32] => [r: i32] where a > 5 && r > 0 {
+			let b = a - 5
+			assert b > 0
+			return b
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Assertions that hold should pass")
+
+	errors = checkSource(t, `
+		func Check[a: i32] => [r: i32] where r > 0 {
+			assert a > 0
+			return a
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Assertions should be verified and then known")
+	test.Assert(t, !errors[0].IsWarning(), "A failed assertion should be an error")
+
+	errors = checkSource(t, `
+		func Check[a: i32] => [r: i32] where r > 0 {
+			assume a > 0
+			return a
+		}
+	`)
+	test.Assert(t, len(errors) == 1 && errors[0].IsWarning(), "Assumptions should be known with a warning")
+
+	errors = checkSource(t, `
+		func Sign[a: i32] => [r: i32] where a > 0 && r == 1 {
+			if (a < 0) {
+				unreachable
+			}
+			return 1
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Contradicting branches should be unreachable")
+
+	errors = checkSource(t, `
+		func Sign[a: i32] => [r: i32] where r >= 0 {
+			if (a < 0) {
+				unreachable
+			}
+			return a
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Reachable code marked unreachable should be reported")
+}
+
+func countWarnings(errors []parser.ParseError) int {
+	var result = 0
+
+	for _, err := range errors {
+		if err.IsWarning() {
+			result = result + 1
+		}
+	}
+
+	return result
+}
+
+func TestConditionWarnings(t *testing.T) {
+	var errors = checkSource(t, `
+		func Check[a: i32] => [r: i32] where a > 0 && r >= 0 {
+			if (a > 0) {
+				return a
+			}
+			return 0
+		}
+	`)
+	test.Assert(t, len(errors) == 1 && countWarnings(errors) == 1, "Conditions that are always true should be reported")
+
+	errors = checkSource(t, `
+		func Check[a: i32] => [r: i32] where a > 0 && r >= 0 {
+			var b = a
+			if (a < 0) {
+				b = 0
+			}
+			return b
+		}
+	`)
+	test.Assert(t, len(errors) == 1 && countWarnings(errors) == 1, "Conditions that are always false should be reported")
+
+	errors = checkSource(t, `
+		func Sign[a: i32] => [r: i32] where (a > 0 && r == 1) || (a <= 0 && r == 0) {
+			if (a > 0) {
+				return 1
+			}
+			return 0
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Conditions should only be reported if they go one way for every precondition")
+
+	errors = checkSource(t, `
+		func Count[] => [r: i32] where r == 10 {
+			var i = 0
+			while (i < 10) invariant i <= 10 {
+				if (i == 0) {
+					i = 1
+				} else {
+					i = i + 1
+				}
+			}
+			return i
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Conditions in loops should be reported only if they go one way in every iteration")
+
+	errors = checkSource(t, `
+		func Count[] => [r: i32] {
+			var i = 10
+			var n = 0
+			while (i < 10) invariant i <= 10 {
+				if (i < 10) {
+					n = 1
+				}
+				i = i + 1
+			}
+			return n
+		}
+	`)
+	test.Assert(t, len(errors) == 1 && countWarnings(errors) == 1, "Finding what a loop assigns should not hide conditions that always go one way")
+
+	errors = checkSource(t, `
+		func Check[a: i32] => [r: i32] where a > 0 && a < 0 && r == 5 {
+			return 1
+		}
+	`)
+	test.Assert(t, len(errors) == 1 && countWarnings(errors) == 1, "Contradicting preconditions should be reported")
+}
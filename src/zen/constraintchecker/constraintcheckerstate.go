@@ -2,15 +2,40 @@ package constraintchecker
 
 import (
 	"zen/boundschecking"
+	"zen/parser"
 )
 
+// The condition of an if statement that is in scope and whether the branch
+// taken is the one where it is true
+type branchCondition struct {
+	expression parser.Expression
+	isTrue     bool
+}
+
+// A structure whose fields were assigned and whose where expression has
+// to be checked again before it can be relied on
+type brokenInvariant struct {
+	path       string
+	expression parser.Expression
+	typeNode   parser.TypeNode
+	assignment *parser.AssignmentStatement
+}
+
 type ConstraintCheckerState struct {
-	knownConstraints []*boundschecking.KnownConstraints
+	knownConstraints     []*boundschecking.KnownConstraints
+	parentMapping        boundschecking.IdentifierMapping
+	modifiedDeclarations map[int]bool
+	branchConditions     []branchCondition
+	brokenInvariants     []brokenInvariant
 }
 
 func NewConstraintCheckerState() *ConstraintCheckerState {
 	return &ConstraintCheckerState{
 		[]*boundschecking.KnownConstraints{boundschecking.NewKnownConstraints()},
+		boundschecking.IdentifierMapping{},
+		make(map[int]bool),
+		nil,
+		nil,
 	}
 }
 
@@ -23,27 +48,68 @@ func (state *ConstraintCheckerState) Copy() *ConstraintCheckerState {
 
 	return &ConstraintCheckerState{
 		constraintCopy,
+		boundschecking.IdentifierMapping{},
+		make(map[int]bool),
+		append([]branchCondition(nil), state.branchConditions...),
+		append([]brokenInvariant(nil), state.brokenInvariants...),
+	}
+}
+
+func (state *ConstraintCheckerState) hasBrokenInvariant(path string) bool {
+	for _, existing := range state.brokenInvariants {
+		if existing.path == path {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (state *ConstraintCheckerState) addBrokenInvariant(invariant brokenInvariant) {
+	if !state.hasBrokenInvariant(invariant.path) {
+		state.brokenInvariants = append(state.brokenInvariants, invariant)
+	}
+}
+
+// A state is reachable if one of its disjuncts has values that satisfy it
+func (state *ConstraintCheckerState) isReachable() bool {
+	for _, knownConstraints := range state.knownConstraints {
+		if !knownConstraints.HasContradiction() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func insertSumGroups(knownConstraints *boundschecking.KnownConstraints, sumGroups []*boundschecking.SumGroup) (bool, error) {
+	for _, sumGroup := range sumGroups {
+		isValid, err := knownConstraints.InsertSumGroup(sumGroup)
+
+		if err != nil || !isValid {
+			return false, err
+		}
 	}
+
+	return true, nil
 }
 
 func (state *ConstraintCheckerState) addSumGroups(newRules []*boundschecking.SumGroup) (bool, error) {
 	var nextRules []*boundschecking.KnownConstraints = nil
 
-	for _, sumGroup := range newRules {
-		for _, existingConstraint := range state.knownConstraints {
-			isValid, err := existingConstraint.InsertSumGroup(sumGroup)
+	for _, existingConstraint := range state.knownConstraints {
+		isValid, err := insertSumGroups(existingConstraint, newRules)
 
-			if err != nil {
-				return false, err
-			} else if isValid {
-				nextRules = append(nextRules, existingConstraint)
-			}
+		if err != nil {
+			return false, err
+		} else if isValid {
+			nextRules = append(nextRules, existingConstraint)
 		}
 	}
 
 	state.knownConstraints = nextRules
 
-	return true, nil
+	return len(state.knownConstraints) != 0, nil
 }
 
 func (state *ConstraintCheckerState) addRules(newRules []*boundschecking.AndGroup) (bool, error) {
@@ -53,22 +119,20 @@ func (state *ConstraintCheckerState) addRules(newRules []*boundschecking.AndGrou
 
 	var nextRules []*boundschecking.KnownConstraints = nil
 
-	for newRuleIndex, andGroup := range newRules {
-		for _, sumGroup := range andGroup.SumGroups {
-			for _, existingConstraint := range state.knownConstraints {
-				var nextConstraint = existingConstraint
+	for _, existingConstraint := range state.knownConstraints {
+		for newRuleIndex, andGroup := range newRules {
+			var nextConstraint = existingConstraint
 
-				if newRuleIndex != len(newRules)-1 {
-					nextConstraint = nextConstraint.Copy()
-				}
+			if newRuleIndex != len(newRules)-1 {
+				nextConstraint = nextConstraint.Copy()
+			}
 
-				isValid, err := nextConstraint.InsertSumGroup(sumGroup)
+			isValid, err := insertSumGroups(nextConstraint, andGroup.SumGroups)
 
-				if err != nil {
-					return false, err
-				} else if isValid {
-					nextRules = append(nextRules, nextConstraint)
-				}
+			if err != nil {
+				return false, err
+			} else if isValid {
+				nextRules = append(nextRules, nextConstraint)
 			}
 		}
 	}
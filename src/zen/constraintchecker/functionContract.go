@@ -0,0 +1,202 @@
+package constraintchecker
+
+import (
+	"zen/boundschecking"
+	"zen/parser"
+)
+
+type functionContract struct {
+	conditions        *boundschecking.OrGroup
+	inputs            []*boundschecking.VariableReference
+	postInputs        []*boundschecking.VariableReference
+	outputs           []*boundschecking.VariableReference
+	expressionMapping map[uint32]parser.Expression
+}
+
+// The contract of a call is the where expression of the called function with
+// its inputs replaced by the arguments and its outputs replaced by new values
+type callContract struct {
+	preConditions     *boundschecking.OrGroup
+	postConditions    []*boundschecking.AndGroup
+	outputs           []*boundschecking.VariableReference
+	expressionMapping map[uint32]parser.Expression
+}
+
+// Inputs get a second value for the state a function returns with. The
+// current mapping is used for old(...) and outside of post clauses
+func useContractMappings(normalizerState *boundschecking.NormalizerState, fnType *parser.FunctionTypeType) []*boundschecking.VariableReference {
+	var oldMapping = normalizerState.GetIdentifierMapping()
+	var postInputs []*boundschecking.VariableReference = nil
+
+	for _, input := range fnType.Input.Entries {
+		var postId = parser.NextUniqueId()
+		normalizerState.UseIdentifierMapping(input.Name, postId)
+		postInputs = append(postInputs, normalizerState.CreateVariableReference(input.Name, postId))
+	}
+
+	var postMapping = normalizerState.GetIdentifierMapping()
+	normalizerState.RestoreIdentifierMapping(oldMapping)
+	normalizerState.UseContractMappings(oldMapping, postMapping)
+
+	return postInputs
+}
+
+func newFunctionContract(normalizerState *boundschecking.NormalizerState, fnType *parser.FunctionTypeType) *functionContract {
+	var result functionContract
+	var previousMapping = normalizerState.GetIdentifierMapping()
+
+	for _, input := range fnType.Input.Entries {
+		normalizerState.UseIdentifierMapping(input.Name, input.UniqueId)
+		result.inputs = append(result.inputs, normalizerState.CreateVariableReference(input.Name, input.UniqueId))
+	}
+
+	for _, output := range fnType.Output.Entries {
+		normalizerState.UseIdentifierMapping(output.Name, output.UniqueId)
+		result.outputs = append(result.outputs, normalizerState.CreateVariableReference(output.Name, output.UniqueId))
+	}
+
+	result.postInputs = useContractMappings(normalizerState, fnType)
+	result.expressionMapping = normalizerState.StartTrackingExpressionMapping()
+
+	if fnType.GetWhereExpression() != nil {
+		result.conditions = normalizerState.NormalizeToOrGroup(fnType.GetWhereExpression())
+	}
+
+	normalizerState.StopTrackingExpressionMapping()
+	normalizerState.StopContractMappings()
+	normalizerState.RestoreIdentifierMapping(previousMapping)
+
+	return &result
+}
+
+func rootNode(node boundschecking.NormalizedNode) boundschecking.NormalizedNode {
+	asProperty, ok := node.(*boundschecking.PropertyReference)
+
+	for ok {
+		node = asProperty.Left
+		asProperty, ok = node.(*boundschecking.PropertyReference)
+	}
+
+	return node
+}
+
+// Checks if any of the values in the sum group are or are a field of the
+// given variables
+func mentionsVariables(sumGroup *boundschecking.SumGroup, variables []*boundschecking.VariableReference) bool {
+	for _, productGroup := range sumGroup.ProductGroups {
+		for _, node := range productGroup.Values.Array {
+			var root = rootNode(node)
+
+			for _, variable := range variables {
+				if root == variable {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+func (contract *functionContract) postVariables() []*boundschecking.VariableReference {
+	return append(append([]*boundschecking.VariableReference(nil), contract.outputs...), contract.postInputs...)
+}
+
+// The parts of conditions that don't mention postVariables. Nil if any of
+// the and groups doesn't have any preconditions since it can always be used
+func preConditionsOf(normalizerState *boundschecking.NormalizerState, conditions *boundschecking.OrGroup, postVariables []*boundschecking.VariableReference) *boundschecking.OrGroup {
+	if conditions == nil {
+		return nil
+	}
+
+	var preConditions []*boundschecking.AndGroup = nil
+
+	for _, andGroup := range conditions.AndGroups {
+		var pre []*boundschecking.SumGroup = nil
+
+		for _, sumGroup := range andGroup.SumGroups {
+			if !mentionsVariables(sumGroup, postVariables) {
+				pre = append(pre, sumGroup)
+			}
+		}
+
+		var preGroup = normalizerState.CreateAndGroup(pre)
+
+		if preGroup == nil {
+			return nil
+		}
+
+		preConditions = append(preConditions, preGroup)
+	}
+
+	return normalizerState.CreateOrGroup(preConditions)
+}
+
+// Arguments are the values given to the function and postArguments the
+// values they have after the call, a nil post argument didn't change. The
+// outputs are filled in even when the conditions can't be instantiated
+func (contract *functionContract) instantiate(normalizerState *boundschecking.NormalizerState, arguments []*boundschecking.SumGroup, postArguments []*boundschecking.SumGroup) (result callContract, err error) {
+	var substitutions = make(map[boundschecking.NormalizedNode]*boundschecking.SumGroup)
+
+	for index, input := range contract.inputs {
+		if index < len(arguments) && arguments[index] != nil {
+			substitutions[input] = arguments[index]
+		} else {
+			var unknown = normalizerState.CreateVariableReference(input.Name, parser.NextUniqueId())
+			substitutions[input] = normalizerState.SumGroupFromNode(unknown)
+		}
+
+		if index < len(postArguments) && postArguments[index] != nil {
+			substitutions[contract.postInputs[index]] = postArguments[index]
+		} else {
+			substitutions[contract.postInputs[index]] = substitutions[input]
+		}
+	}
+
+	for _, output := range contract.outputs {
+		var outputValue = normalizerState.CreateVariableReference(output.Name, parser.NextUniqueId())
+		result.outputs = append(result.outputs, outputValue)
+		substitutions[output] = normalizerState.SumGroupFromNode(outputValue)
+	}
+
+	result.expressionMapping = make(map[uint32]parser.Expression)
+
+	if contract.conditions == nil {
+		return result, nil
+	}
+
+	// the parts of the contract that are about the state after the call
+	// are told apart before inputs and outputs are replaced
+	var preConditions = preConditionsOf(normalizerState, contract.conditions, contract.postVariables())
+
+	if preConditions != nil {
+		result.preConditions, err = preConditions.SubstituteNodes(
+			normalizerState,
+			substitutions,
+			contract.expressionMapping,
+			result.expressionMapping,
+		)
+
+		if err != nil {
+			return result, err
+		}
+	}
+
+	conditions, err := contract.conditions.SubstituteNodes(
+		normalizerState,
+		substitutions,
+		contract.expressionMapping,
+		result.expressionMapping,
+	)
+
+	if err != nil {
+		result.preConditions = nil
+		return result, err
+	}
+
+	if conditions != nil {
+		result.postConditions = conditions.AndGroups
+	}
+
+	return result, nil
+}
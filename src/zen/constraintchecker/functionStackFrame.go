@@ -12,6 +12,8 @@ type preAndPostConditions struct {
 
 type functionStackFrame struct {
 	outputNames       []*boundschecking.VariableReference
+	postInputs        []*boundschecking.VariableReference
+	outputTypes       []parser.TypeNode
 	conditions        []preAndPostConditions
 	currentCondition  int
 	expressionMapping map[uint32]parser.Expression
@@ -27,13 +29,16 @@ func newFunctionStackFrame(normalizerState *boundschecking.NormalizerState, fnTy
 
 	for _, outputType := range fnType.Output.Entries {
 		result.outputNames = append(result.outputNames, normalizerState.CreateVariableReference(outputType.Name, outputType.UniqueId))
+		result.outputTypes = append(result.outputTypes, outputType.Type)
 	}
 
+	result.postInputs = useContractMappings(normalizerState, fnType)
 	result.expressionMapping = normalizerState.StartTrackingExpressionMapping()
 
 	var conditions = normalizerState.NormalizeToOrGroup(fnType.GetWhereExpression())
 
 	normalizerState.StopTrackingExpressionMapping()
+	normalizerState.StopContractMappings()
 
 	for _, andGroup := range conditions.AndGroups {
 		preGroup, postGroup := splitAndGroup(&result, normalizerState, andGroup)
@@ -65,30 +70,10 @@ func newFunctionStackFrame(normalizerState *boundschecking.NormalizerState, fnTy
 	return &result
 }
 
-func isPostConditionIdentifier(fnStackFrame *functionStackFrame, name string) bool {
-	for _, outputName := range fnStackFrame.outputNames {
-		if outputName.Name == name {
-			return true
-		}
-	}
-
-	return false
-}
-
-func isPostConditionGroup(fnStackFrame *functionStackFrame, andGroup *boundschecking.SumGroup) bool {
-	for _, productGroup := range andGroup.ProductGroups {
-		for _, node := range productGroup.Values.Array {
-			asIdentifier, ok := node.(*boundschecking.VariableReference)
-
-			if ok {
-				if isPostConditionIdentifier(fnStackFrame, asIdentifier.Name) {
-					return true
-				}
-			}
-		}
-	}
-
-	return false
+// Clauses about outputs or about inputs inside of post clauses can only be
+// checked when the function returns
+func isPostConditionGroup(fnStackFrame *functionStackFrame, sumGroup *boundschecking.SumGroup) bool {
+	return mentionsVariables(sumGroup, fnStackFrame.outputNames) || mentionsVariables(sumGroup, fnStackFrame.postInputs)
 }
 
 func splitAndGroup(fnStackFrame *functionStackFrame, normalizerState *boundschecking.NormalizerState, andGroup *boundschecking.AndGroup) (preGroup *boundschecking.AndGroup, postGroup *boundschecking.AndGroup) {
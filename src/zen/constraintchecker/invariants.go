@@ -0,0 +1,485 @@
+package constraintchecker
+
+import (
+	"zen/boundschecking"
+	"zen/parser"
+	"zen/tokenizer"
+)
+
+func hasInvariant(typeNode parser.TypeNode) bool {
+	if typeNode == nil || typeNode.GetWhereExpression() == nil {
+		return false
+	}
+
+	_, isVoid := typeNode.GetWhereExpression().(*parser.VoidExpression)
+	return !isVoid
+}
+
+// The where expression of a structure type rewritten to be about the fields
+// of the value at node
+func (constraintChecker *ConstraintChecker) typeInvariant(node boundschecking.NormalizedNode, typeNode parser.TypeNode) (*boundschecking.OrGroup, map[uint32]parser.Expression, error) {
+	var normalizerState = constraintChecker.normalizerState
+
+	return constraintChecker.instantiateInvariant(typeNode, func(name string) *boundschecking.SumGroup {
+		if name == "self" {
+			return normalizerState.SumGroupFromNode(node)
+		}
+
+		return normalizerState.SumGroupFromNode(normalizerState.CreatePropertyReference(node, name, 0))
+	})
+}
+
+// The where expression of a structure type with its fields replaced by the
+// values valueOf gives for them
+func (constraintChecker *ConstraintChecker) instantiateInvariant(typeNode parser.TypeNode, valueOf func(name string) *boundschecking.SumGroup) (*boundschecking.OrGroup, map[uint32]parser.Expression, error) {
+	if !hasInvariant(typeNode) {
+		return nil, nil, nil
+	}
+
+	typeConstraints, err := constraintChecker.typeDiffer.GetConstraintsForType(typeNode)
+
+	if err != nil || typeConstraints.constraints == nil {
+		return nil, nil, nil
+	}
+
+	var normalizerState = constraintChecker.normalizerState
+	var substitutions = make(map[boundschecking.NormalizedNode]*boundschecking.SumGroup)
+
+	for _, variable := range typeConstraints.variables {
+		substitutions[normalizerState.CreateVariableReference(variable.name, variable.at)] = valueOf(variable.name)
+	}
+
+	var expressionMapping = make(map[uint32]parser.Expression)
+	result, err := typeConstraints.constraints.SubstituteNodes(
+		normalizerState,
+		substitutions,
+		typeConstraints.expressionMapping,
+		expressionMapping,
+	)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return result, expressionMapping, nil
+}
+
+// Values of a structure type satisfy its where expression whenever they are
+// passed around so it can be assumed for inputs, outputs and variables
+func (constraintChecker *ConstraintChecker) assumeInvariants(node boundschecking.NormalizedNode, typeNode parser.TypeNode, depth int) {
+	var state = constraintChecker.peekState()
+	asStructure, ok := typeNode.(*parser.StructureTypeType)
+
+	if state == nil || node == nil || !ok || depth > maxTypeRangeDepth {
+		return
+	}
+
+	for _, entry := range asStructure.Entries {
+		constraintChecker.assumeInvariants(constraintChecker.normalizerState.CreatePropertyReference(node, entry.Name, 0), entry.Type, depth+1)
+	}
+
+	invariant, _, _ := constraintChecker.typeInvariant(node, typeNode)
+
+	if invariant != nil && len(invariant.AndGroups) != 0 {
+		state.addRules(invariant.AndGroups)
+	}
+}
+
+func (constraintChecker *ConstraintChecker) assumeTypeFacts(node boundschecking.NormalizedNode, typeNode parser.TypeNode) {
+	constraintChecker.assumeTypeRange(node, typeNode, 0)
+	constraintChecker.assumeInvariants(node, typeNode, 0)
+}
+
+// Carries what is known about the fields of a value over to a new value of
+// the same structure. The field at the end of skip is left out and mutable
+// fields are only kept if keepMutable is set
+func (constraintChecker *ConstraintChecker) assumeSameFields(previous boundschecking.NormalizedNode, next boundschecking.NormalizedNode, typeNode parser.TypeNode, skip []string, keepMutable bool, depth int) {
+	var normalizerState = constraintChecker.normalizerState
+
+	if depth > maxTypeRangeDepth {
+		return
+	}
+
+	_, isInteger := typeNode.(*parser.IntegerType)
+	_, isBoolean := typeNode.(*parser.BooleanType)
+
+	if isInteger || isBoolean {
+		constraintChecker.assumeEquality(tokenizer.SourceLocation{}, normalizerState.SumGroupFromNode(previous), next)
+	} else if _, ok := typeNode.(*parser.ArrayTypeType); ok {
+		var previousLength = normalizerState.CreatePropertyReference(previous, "length", 0)
+		var nextLength = normalizerState.CreatePropertyReference(next, "length", 0)
+		constraintChecker.assumeEquality(tokenizer.SourceLocation{}, normalizerState.SumGroupFromNode(previousLength), nextLength)
+	} else if asStructure, ok := typeNode.(*parser.StructureTypeType); ok {
+		for _, entry := range asStructure.Entries {
+			var entrySkip []string = nil
+
+			if len(skip) > 0 && skip[0] == entry.Name {
+				if len(skip) == 1 {
+					continue
+				}
+
+				entrySkip = skip[1:]
+			} else if entry.IsMutable && !keepMutable {
+				continue
+			}
+
+			constraintChecker.assumeSameFields(
+				normalizerState.CreatePropertyReference(previous, entry.Name, 0),
+				normalizerState.CreatePropertyReference(next, entry.Name, 0),
+				entry.Type,
+				entrySkip,
+				keepMutable,
+				depth+1,
+			)
+		}
+	}
+}
+
+func hasMutableFields(typeNode parser.TypeNode, depth int) bool {
+	asStructure, ok := typeNode.(*parser.StructureTypeType)
+
+	if !ok || depth > maxTypeRangeDepth {
+		return false
+	}
+
+	for _, entry := range asStructure.Entries {
+		if entry.IsMutable || hasMutableFields(entry.Type, depth+1) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Splits a.b.c into the identifier a and the fields b, c
+func propertyPath(expression parser.Expression) (*parser.Identifier, []string) {
+	asProperty, ok := expression.(*parser.PropertyExpression)
+
+	if ok {
+		root, path := propertyPath(asProperty.Left)
+
+		if root == nil {
+			return nil, nil
+		}
+
+		return root, append(path, asProperty.Property.Value)
+	}
+
+	asIdentifier, ok := expression.(*parser.Identifier)
+
+	if ok {
+		return asIdentifier, nil
+	}
+
+	return nil, nil
+}
+
+func formatPath(root *parser.Identifier, path []string) string {
+	var result = root.Token.Value
+
+	for _, name := range path {
+		result = result + "." + name
+	}
+
+	return result
+}
+
+// Assigning a field gives the variable holding the structure a new value
+// that only differs from the previous one in the assigned field. Every
+// structure along the way may no longer satisfy its where expression
+func (constraintChecker *ConstraintChecker) assignField(assignment *parser.AssignmentStatement, target *parser.PropertyExpression, fits bool) {
+	target.Left.Accept(constraintChecker)
+
+	var normalizerState = constraintChecker.normalizerState
+	var state = constraintChecker.peekState()
+	root, path := propertyPath(target)
+
+	if state == nil || root == nil {
+		return
+	}
+
+	value, valueErr := constraintChecker.normalizeValue(assignment.Value)
+	previous, err := normalizerState.NormalizeToNode(root)
+
+	if err != nil {
+		return
+	}
+
+	var next boundschecking.NormalizedNode = normalizerState.ReassignIdentifier(root.Token.Value)
+	source, _ := normalizerState.GetIdentifierSource(root.Token.Value)
+	state.modifiedDeclarations[source.Declaration] = true
+
+	constraintChecker.assumeTypeRange(next, root.GetType(), 0)
+	constraintChecker.assumeSameFields(previous, next, root.GetType(), path, true, 0)
+
+	var field = next
+
+	for _, name := range path {
+		field = normalizerState.CreatePropertyReference(field, name, 0)
+	}
+
+	if fits && valueErr == nil {
+		constraintChecker.assumeEquality(assignment.Begin(), value, field)
+	}
+
+	var structure parser.Expression = target.Left
+
+	for index := len(path) - 1; index >= 0; index-- {
+		if hasInvariant(structure.GetType()) {
+			state.addBrokenInvariant(brokenInvariant{
+				formatPath(root, path[:index]),
+				structure,
+				structure.GetType(),
+				assignment,
+			})
+		}
+
+		asProperty, ok := structure.(*parser.PropertyExpression)
+
+		if !ok {
+			break
+		}
+
+		structure = asProperty.Left
+	}
+}
+
+// Callees can assign the mutable fields of the structures they are given so
+// only the immutable fields are known to be the same after a call. Returns
+// the values of the arguments after the call, nil for the ones that can't
+// have changed
+func (constraintChecker *ConstraintChecker) invalidateArguments(arguments []parser.Expression) []*boundschecking.SumGroup {
+	var normalizerState = constraintChecker.normalizerState
+	var state = constraintChecker.peekState()
+	var result = make([]*boundschecking.SumGroup, len(arguments))
+
+	if state == nil {
+		return result
+	}
+
+	for index, argument := range arguments {
+		asIdentifier, ok := argument.(*parser.Identifier)
+
+		if !ok || !hasMutableFields(argument.GetType(), 0) {
+			continue
+		}
+
+		previous, err := normalizerState.NormalizeToNode(asIdentifier)
+
+		if err != nil {
+			continue
+		}
+
+		var next = normalizerState.ReassignIdentifier(asIdentifier.Token.Value)
+		source, _ := normalizerState.GetIdentifierSource(asIdentifier.Token.Value)
+		state.modifiedDeclarations[source.Declaration] = true
+
+		constraintChecker.assumeTypeFacts(next, argument.GetType())
+		constraintChecker.assumeSameFields(previous, next, argument.GetType(), nil, false, 0)
+		result[index] = normalizerState.SumGroupFromNode(next)
+	}
+
+	return result
+}
+
+func (constraintChecker *ConstraintChecker) checkInvariant(state *ConstraintCheckerState, broken brokenInvariant) ([]*boundschecking.SumGroup, map[uint32]parser.Expression, error) {
+	node, err := constraintChecker.normalizerState.NormalizeToNode(broken.expression)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	invariant, expressionMapping, err := constraintChecker.typeInvariant(node, broken.typeNode)
+
+	if err != nil {
+		return nil, nil, err
+	} else if invariant == nil || len(invariant.AndGroups) == 0 {
+		return nil, nil, nil
+	}
+
+	result, err := state.checkOrGroup(invariant)
+	return result, expressionMapping, err
+}
+
+// Invariants restored before the end of a branch don't have to be checked
+// again after it. They are removed from the state and returned
+func (constraintChecker *ConstraintChecker) takeRestoredInvariants(state *ConstraintCheckerState) []brokenInvariant {
+	var restored []brokenInvariant = nil
+	var remaining []brokenInvariant = nil
+
+	for _, broken := range state.brokenInvariants {
+		result, _, err := constraintChecker.checkInvariant(state, broken)
+
+		if err == nil && len(result) == 0 {
+			restored = append(restored, broken)
+		} else {
+			remaining = append(remaining, broken)
+		}
+	}
+
+	state.brokenInvariants = remaining
+	return restored
+}
+
+// Structures with assigned fields have to satisfy their where expressions
+// again before they can be seen by other functions
+func (constraintChecker *ConstraintChecker) checkInvariants(at tokenizer.SourceLocation, when string) {
+	var state = constraintChecker.peekState()
+
+	if state == nil {
+		return
+	}
+
+	var brokenInvariants = state.brokenInvariants
+	state.brokenInvariants = nil
+
+	for _, broken := range brokenInvariants {
+		result, expressionMapping, err := constraintChecker.checkInvariant(state, broken)
+
+		if err != nil {
+			constraintChecker.reportErrorMessage(at, err.Error())
+		} else if len(result) > 0 {
+			var locations = formatErrorWithConstraints(expressionMapping, "With invariant\n", result)
+			locations = append(locations, parser.CreateError(broken.assignment.Begin(), "After assignment at\n"))
+
+			constraintChecker.reportError(parser.CreateErrorWithMultipleLocations(
+				at,
+				"Could not verify invariant of '"+broken.path+"' holds "+when,
+				locations,
+			))
+		}
+	}
+}
+
+// The field of the structure type an entry of a literal is given to
+func literalField(structure *parser.StructureTypeType, literal *parser.StructureExpression, index int) *parser.StructureNamedEntryType {
+	if literal.Entries[index].Name == nil {
+		if index < len(structure.Entries) {
+			return structure.Entries[index]
+		}
+
+		return nil
+	}
+
+	for _, entry := range structure.Entries {
+		if entry.Name == literal.Entries[index].Name.Value {
+			return entry
+		}
+	}
+
+	return nil
+}
+
+// Gives the field at node the value of an expression. Structures other than
+// literals can be changed through other references so only their immutable
+// fields are known to stay the same
+func (constraintChecker *ConstraintChecker) assumeFieldValue(value parser.Expression, node boundschecking.NormalizedNode, typeNode parser.TypeNode) {
+	var normalizerState = constraintChecker.normalizerState
+
+	_, isInteger := typeNode.(*parser.IntegerType)
+	_, isBoolean := typeNode.(*parser.BooleanType)
+
+	if isInteger || isBoolean {
+		sumGroup, err := constraintChecker.normalizeValue(value)
+
+		if err == nil {
+			constraintChecker.assumeEquality(value.Begin(), sumGroup, node)
+		}
+
+		return
+	}
+
+	valueNode, err := normalizerState.NormalizeToNode(value)
+
+	if err == nil {
+		_, isLiteral := value.(*parser.StructureExpression)
+		constraintChecker.assumeSameFields(valueNode, node, typeNode, nil, isLiteral, 0)
+	}
+}
+
+// A structure literal is a new value whose fields are its entries
+func (constraintChecker *ConstraintChecker) assumeLiteralFields(node boundschecking.NormalizedNode, literal *parser.StructureExpression) {
+	for index, entry := range literal.Entries {
+		var field = literalField(literal.Type, literal, index)
+
+		if field != nil {
+			constraintChecker.assumeFieldValue(entry.Expr, constraintChecker.normalizerState.CreatePropertyReference(node, field.Name, 0), field.Type)
+		}
+	}
+}
+
+// Variables and outputs given a structure literal have the same fields as
+// the literal
+func (constraintChecker *ConstraintChecker) assumeLiteralValue(value parser.Expression, node boundschecking.NormalizedNode, typeNode parser.TypeNode) {
+	if _, ok := value.(*parser.StructureExpression); ok {
+		constraintChecker.assumeFieldValue(value, node, typeNode)
+	}
+}
+
+// The entries of a structure literal have to fit in the fields they are
+// given to and satisfy the where expression of the structure
+func (constraintChecker *ConstraintChecker) checkStructureLiteral(literal *parser.StructureExpression, targetType *parser.StructureTypeType) bool {
+	var normalizerState = constraintChecker.normalizerState
+	var fits = true
+	var components = make(map[string]*boundschecking.SumGroup)
+
+	for index, entry := range literal.Entries {
+		var field = literalField(targetType, literal, index)
+
+		if field == nil {
+			continue
+		}
+
+		if !constraintChecker.checkFits(entry.Expr, field.Type) {
+			fits = false
+		}
+
+		component, err := constraintChecker.normalizeValue(entry.Expr)
+
+		if err == nil {
+			components[field.Name] = component
+		}
+	}
+
+	literalNode, err := normalizerState.NormalizeToNode(literal)
+
+	if err != nil {
+		return fits
+	}
+
+	invariant, expressionMapping, err := constraintChecker.instantiateInvariant(targetType, func(name string) *boundschecking.SumGroup {
+		component, ok := components[name]
+
+		if ok {
+			return component
+		} else if name == "self" {
+			return normalizerState.SumGroupFromNode(literalNode)
+		}
+
+		return normalizerState.SumGroupFromNode(normalizerState.CreatePropertyReference(literalNode, name, 0))
+	})
+
+	if err != nil {
+		constraintChecker.reportErrorMessage(literal.Begin(), err.Error())
+		return false
+	} else if invariant == nil || len(invariant.AndGroups) == 0 {
+		return fits
+	}
+
+	result, err := constraintChecker.peekState().checkOrGroup(invariant)
+
+	if err != nil {
+		constraintChecker.reportErrorMessage(literal.Begin(), err.Error())
+		return false
+	} else if len(result) > 0 {
+		constraintChecker.reportError(parser.CreateSpanErrorWithMultipleLocations(
+			literal.Begin(),
+			literal.End(),
+			"Could not verify structure satisfies the where expression of its type",
+			formatErrorWithConstraints(expressionMapping, "With invariant\n", result),
+		))
+		return false
+	}
+
+	return fits
+}
@@ -0,0 +1,92 @@
+package constraintchecker
+
+import (
+	"zen/parser"
+)
+
+// A function is checked once for every set of preconditions it has so a
+// condition is only reported when it went the same way every time it was
+// reached
+const (
+	outcomeTrue = 1 << iota
+	outcomeFalse
+)
+
+type outcomeTracker struct {
+	outcomes map[parser.ParseNode]int
+	order    []parser.ParseNode
+}
+
+func newOutcomeTracker() *outcomeTracker {
+	return &outcomeTracker{
+		make(map[parser.ParseNode]int),
+		nil,
+	}
+}
+
+func (tracker *outcomeTracker) record(node parser.ParseNode, canBeTrue bool, canBeFalse bool) {
+	var outcome, ok = tracker.outcomes[node]
+
+	if !ok {
+		tracker.order = append(tracker.order, node)
+	}
+
+	if canBeTrue {
+		outcome = outcome | outcomeTrue
+	}
+
+	if canBeFalse {
+		outcome = outcome | outcomeFalse
+	}
+
+	tracker.outcomes[node] = outcome
+}
+
+// A branch that starts with unreachable is expected to never be taken so
+// it doesn't need a warning
+func isMarkedUnreachable(branch parser.ParseNode) bool {
+	asBody, ok := branch.(*parser.Body)
+
+	if !ok || len(asBody.Statements) == 0 {
+		return false
+	}
+
+	_, ok = asBody.Statements[0].(*parser.UnreachableStatement)
+	return ok
+}
+
+// Whether a condition could be reached at all is only known after the
+// outermost function is checked
+func (constraintChecker *ConstraintChecker) reportOutcomes() {
+	var tracker = constraintChecker.outcomes
+
+	for _, node := range tracker.order {
+		var outcome = tracker.outcomes[node]
+
+		if asIf, ok := node.(*parser.IfStatement); ok {
+			var condition = asIf.Expresssion
+
+			if outcome == outcomeTrue {
+				constraintChecker.reportError(parser.CreateSpanWarning(condition.Begin(), condition.End(), "Condition is always true"))
+			} else if outcome == outcomeFalse {
+				constraintChecker.reportError(parser.CreateSpanWarning(condition.Begin(), condition.End(), "Condition is always false so the branch is never taken"))
+			}
+		} else if asFunction, ok := node.(*parser.Function); ok && outcome == outcomeFalse {
+			var where = asFunction.Type.GetWhereExpression()
+
+			// types that contradict themselves are reported with their
+			// where expression
+			if where == nil {
+				continue
+			}
+
+			constraintChecker.reportError(parser.CreateSpanWarning(
+				where.Begin(),
+				where.End(),
+				"The preconditions of the function contradict each other so every postcondition holds",
+			))
+		}
+	}
+
+	constraintChecker.outcomes = newOutcomeTracker()
+}
@@ -0,0 +1,185 @@
+package loader
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"zen/parser"
+	"zen/source"
+)
+
+// A module is every file that declares the same module name. Files without
+// a module declaration are a module of their own with an empty name
+type Module struct {
+	Name    string
+	Files   []*parser.FileDefinition
+	Imports []*Module
+}
+
+type loaderState struct {
+	root    string
+	modules map[string]*Module
+	loading map[*Module]bool
+	loaded  map[*Module]bool
+	ordered []*Module
+	errors  []parser.ParseError
+}
+
+// The files of module a.b are root/a/b.zen or every .zen file in the
+// directory root/a/b
+func modulePaths(root string, name string) []string {
+	var path = filepath.Join(append([]string{root}, strings.Split(name, ".")...)...)
+
+	if _, err := os.Stat(path + ".zen"); err == nil {
+		return []string{path + ".zen"}
+	}
+
+	infos, err := ioutil.ReadDir(path)
+
+	if err != nil {
+		return nil
+	}
+
+	var result []string = nil
+
+	for _, info := range infos {
+		if !info.IsDir() && strings.HasSuffix(info.Name(), ".zen") {
+			result = append(result, filepath.Join(path, info.Name()))
+		}
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+func (state *loaderState) parse(src *source.Source) *parser.FileDefinition {
+	fileDef, errors := parser.Parse(src)
+	state.errors = append(state.errors, errors...)
+	return fileDef
+}
+
+// Modules named by an import are read from the source root unless one of
+// the files given to Load already declared them
+func (state *loaderState) findModule(importPath *parser.ModulePath) *Module {
+	var name = importPath.Name()
+
+	if module, ok := state.modules[name]; ok {
+		return module
+	}
+
+	var paths = modulePaths(state.root, name)
+
+	if len(paths) == 0 {
+		state.errors = append(state.errors, parser.CreateError(importPath.Begin(), "Could not find module '"+name+"'"))
+		return nil
+	}
+
+	var module = &Module{name, nil, nil}
+	state.modules[name] = module
+
+	for _, path := range paths {
+		src, err := source.SourceFromFile(path)
+
+		if err != nil {
+			state.errors = append(state.errors, parser.CreateError(importPath.Begin(), "Could not load module '"+name+"': "+err.Error()))
+			continue
+		}
+
+		var fileDef = state.parse(src)
+
+		if fileDef.Module == nil || fileDef.Module.Name() != name {
+			state.errors = append(state.errors, parser.CreateError(fileDef.Begin(), "Expected file to declare module '"+name+"'"))
+		}
+
+		module.Files = append(module.Files, fileDef)
+	}
+
+	return module
+}
+
+func containsModule(modules []*Module, module *Module) bool {
+	for _, other := range modules {
+		if other == module {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Imports are loaded before the module so modules end up in an order where
+// every module comes after the modules it imports
+func (state *loaderState) load(module *Module) {
+	if state.loaded[module] {
+		return
+	}
+
+	state.loading[module] = true
+
+	for _, file := range module.Files {
+		for _, importPath := range file.Imports {
+			var imported = state.findModule(importPath)
+
+			if imported == nil {
+				continue
+			} else if state.loading[imported] {
+				state.errors = append(state.errors, parser.CreateError(importPath.Begin(), "Import cycle through module '"+imported.Name+"'"))
+				continue
+			}
+
+			state.load(imported)
+
+			if !containsModule(module.Imports, imported) {
+				module.Imports = append(module.Imports, imported)
+			}
+		}
+	}
+
+	state.loading[module] = false
+	state.loaded[module] = true
+	state.ordered = append(state.ordered, module)
+}
+
+// Parses the given sources and every module they import from root. The
+// modules are returned with imported modules first
+func Load(root string, sources []*source.Source) ([]*Module, []parser.ParseError) {
+	var state = &loaderState{
+		root,
+		make(map[string]*Module),
+		make(map[*Module]bool),
+		make(map[*Module]bool),
+		nil,
+		nil,
+	}
+
+	var given []*Module = nil
+
+	for _, src := range sources {
+		var fileDef = state.parse(src)
+
+		if fileDef.Module == nil {
+			given = append(given, &Module{"", []*parser.FileDefinition{fileDef}, nil})
+			continue
+		}
+
+		var name = fileDef.Module.Name()
+		module, ok := state.modules[name]
+
+		if !ok {
+			module = &Module{name, nil, nil}
+			state.modules[name] = module
+			given = append(given, module)
+		}
+
+		module.Files = append(module.Files, fileDef)
+	}
+
+	for _, module := range given {
+		state.load(module)
+	}
+
+	return state.ordered, state.errors
+}
@@ -0,0 +1,53 @@
+package loader
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"zen/source"
+	"zen/test"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	root, err := ioutil.TempDir("", "zen-loader")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(root)
+
+	writeFile(t, filepath.Join(root, "geometry", "point.zen"), "module geometry\nimport util.math\n")
+	writeFile(t, filepath.Join(root, "geometry", "line.zen"), "module geometry\n")
+	writeFile(t, filepath.Join(root, "util", "math.zen"), "module util.math\n")
+
+	modules, errors := Load(root, []*source.Source{source.SourceFromString("import geometry\nimport util.math\n")})
+
+	test.Assert(t, len(errors) == 0, "Imports should be found in the source root")
+	test.Assert(t, len(modules) == 3, "Each module should be loaded once")
+	test.Assert(t, modules[0].Name == "util.math" && modules[1].Name == "geometry", "Imported modules should come first")
+	test.Assert(t, len(modules[1].Files) == 2, "Every file in the module directory should be loaded")
+	test.Assert(t, len(modules[2].Imports) == 2, "Modules should know their imports")
+
+	writeFile(t, filepath.Join(root, "cycle", "a.zen"), "module cycle.a\nimport cycle.b\n")
+	writeFile(t, filepath.Join(root, "cycle", "b.zen"), "module cycle.b\nimport cycle.a\n")
+
+	_, errors = Load(root, []*source.Source{source.SourceFromString("import cycle.a\nimport missing\n")})
+	test.Assert(t, len(errors) == 2, "Import cycles and missing modules should be reported")
+
+	writeFile(t, filepath.Join(root, "wrong.zen"), "module right\n")
+
+	_, errors = Load(root, []*source.Source{source.SourceFromString("import wrong\n")})
+	test.Assert(t, len(errors) == 1, "Files should declare the module they are loaded as")
+}
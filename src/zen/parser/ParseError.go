@@ -9,7 +9,22 @@ import (
 
 type ParseError struct {
 	At      tokenizer.SourceLocation
+	end     int
 	message string
+	warning bool
+}
+
+// Warnings are reported like errors but don't fail a check
+func (parseError ParseError) IsWarning() bool {
+	return parseError.warning
+}
+
+func (parseError ParseError) formatLocation() string {
+	if parseError.end > parseError.At.At {
+		return source.FormatSpan(parseError.At.Source, parseError.At.At, parseError.end)
+	} else {
+		return source.FormatLine(parseError.At.Source, parseError.At.At)
+	}
 }
 
 func CreateErrorWithMultipleLocations(at tokenizer.SourceLocation, mainMessage string, otherErrors []ParseError) (result ParseError) {
@@ -22,19 +37,42 @@ func CreateErrorWithMultipleLocations(at tokenizer.SourceLocation, mainMessage s
 		if len(parseError.message) != 0 {
 			messageResult.WriteString(parseError.message)
 		}
-		messageResult.WriteString(source.FormatLine(parseError.At.Source, parseError.At.At))
+		messageResult.WriteString(parseError.formatLocation())
 	}
 
-	return ParseError{at, messageResult.String()}
+	return ParseError{at, 0, messageResult.String(), false}
 }
 
 func CreateError(at tokenizer.SourceLocation, message string) (result ParseError) {
 	return ParseError{
 		at,
+		0,
 		message,
+		false,
 	}
 }
 
+// Errors with a span mark all of the source between at and end
+func CreateSpanErrorWithMultipleLocations(at tokenizer.SourceLocation, end tokenizer.SourceLocation, mainMessage string, otherErrors []ParseError) (result ParseError) {
+	result = CreateErrorWithMultipleLocations(at, mainMessage, otherErrors)
+	result.end = end.At
+	return result
+}
+
+func CreateSpanError(at tokenizer.SourceLocation, end tokenizer.SourceLocation, message string) (result ParseError) {
+	return CreateSpanErrorWithMultipleLocations(at, end, message, nil)
+}
+
+func CreateSpanWarning(at tokenizer.SourceLocation, end tokenizer.SourceLocation, message string) (result ParseError) {
+	result = CreateSpanError(at, end, message)
+	result.warning = true
+	return result
+}
+
 func FormatError(parseError ParseError) (result string) {
-	return fmt.Sprintf("%s\n%s", parseError.message, source.FormatLine(parseError.At.Source, parseError.At.At))
+	if parseError.warning {
+		return fmt.Sprintf("Warning: %s\n%s", parseError.message, parseError.formatLocation())
+	}
+
+	return fmt.Sprintf("%s\n%s", parseError.message, parseError.formatLocation())
 }
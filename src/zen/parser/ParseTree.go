@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"strings"
 	"zen/tokenizer"
 )
 
@@ -27,19 +28,33 @@ type Visitor interface {
 	VisitPropertyExpression(exp *PropertyExpression)
 	VisitBinaryExpression(exp *BinaryExpression)
 	VisitStructureExpression(exp *StructureExpression)
+	VisitCallExpression(exp *CallExpression)
+	VisitIndexExpression(exp *IndexExpression)
+	VisitMatchExpression(match *MatchExpression)
 	VisitFunction(function *Function)
 	VisitIf(ifStatement *IfStatement)
+	VisitWhile(whileStatement *WhileStatement)
+	VisitForeach(foreach *ForeachStatement)
+	VisitMatch(match *MatchStatement)
 	VisitBody(body *Body)
 
 	VisitReturn(ret *ReturnStatement)
+	VisitVarDef(varDef *VariableDefinition)
+	VisitDestructure(destructure *DestructuringDefinition)
+	VisitAssignment(assignment *AssignmentStatement)
+	VisitAssert(assert *AssertStatement)
+	VisitUnreachable(unreachable *UnreachableStatement)
 
 	VisitNamedType(namedType *NamedType)
 	VisitStructureType(structure *StructureType)
 	VisitFunctionType(fn *FunctionType)
+	VisitArrayType(array *ArrayType)
 	VisitWhereType(where *WhereType)
+	VisitUnionType(union *UnionType)
 
 	VisitTypeDef(typeDef *TypeDefinition)
 	VisitFnDef(fnDef *FunctionDefinition)
+	VisitConst(constDef *ConstantDefinition)
 	VisitFile(fileDef *FileDefinition)
 }
 
@@ -97,9 +112,12 @@ func (node *VoidExpression) End() tokenizer.SourceLocation {
 	return node.At
 }
 
+// Constant is the definition an identifier refers to when it names a
+// constant, nil otherwise
 type Identifier struct {
-	Token *tokenizer.Token
-	Type  TypeNode
+	Token    *tokenizer.Token
+	Type     TypeNode
+	Constant *ConstantDefinition
 }
 
 func (node *Identifier) Accept(visitor Visitor) {
@@ -234,6 +252,55 @@ func (node *StructureExpression) End() tokenizer.SourceLocation {
 	return node.closeBracket.End()
 }
 
+type CallExpression struct {
+	Function     Expression
+	open         *tokenizer.Token
+	Arguments    []Expression
+	close        *tokenizer.Token
+	Type         TypeNode
+	FunctionType *FunctionTypeType
+}
+
+func (node *CallExpression) Accept(visitor Visitor) {
+	visitor.VisitCallExpression(node)
+}
+
+func (node *CallExpression) GetType() TypeNode {
+	return node.Type
+}
+
+func (node *CallExpression) Begin() tokenizer.SourceLocation {
+	return node.Function.Begin()
+}
+
+func (node *CallExpression) End() tokenizer.SourceLocation {
+	return node.close.End()
+}
+
+type IndexExpression struct {
+	Left  Expression
+	open  *tokenizer.Token
+	Index Expression
+	close *tokenizer.Token
+	Type  TypeNode
+}
+
+func (node *IndexExpression) Accept(visitor Visitor) {
+	visitor.VisitIndexExpression(node)
+}
+
+func (node *IndexExpression) GetType() TypeNode {
+	return node.Type
+}
+
+func (node *IndexExpression) Begin() tokenizer.SourceLocation {
+	return node.Left.Begin()
+}
+
+func (node *IndexExpression) End() tokenizer.SourceLocation {
+	return node.close.End()
+}
+
 type Body struct {
 	open       *tokenizer.Token
 	Statements []Statement
@@ -258,6 +325,11 @@ func (node *Body) End() tokenizer.SourceLocation {
 	return node.end.End()
 }
 
+// The location of the closing brace
+func (node *Body) CloseAt() tokenizer.SourceLocation {
+	return node.end.At
+}
+
 type IfStatement struct {
 	ifKeyword   *tokenizer.Token
 	Expresssion Expression
@@ -286,9 +358,113 @@ func (node *IfStatement) End() tokenizer.SourceLocation {
 	}
 }
 
+// A loop with an optional invariant that holds before every check of the
+// condition and an optional measure that gets smaller every iteration
+type WhileStatement struct {
+	whileKeyword *tokenizer.Token
+	Condition    Expression
+	Invariant    Expression
+	Decreases    Expression
+	Body         *Body
+}
+
+func (node *WhileStatement) Accept(visitor Visitor) {
+	visitor.VisitWhile(node)
+}
+
+func (node *WhileStatement) Begin() tokenizer.SourceLocation {
+	return node.whileKeyword.At
+}
+
+func (node *WhileStatement) End() tokenizer.SourceLocation {
+	return node.Body.End()
+}
+
+// Runs the body once for every element of an array. Index is nil when the
+// loop doesn't name it
+type ForeachStatement struct {
+	foreachKeyword *tokenizer.Token
+	Element        *tokenizer.Token
+	Index          *tokenizer.Token
+	Collection     Expression
+	Body           *Body
+	ElementId      int
+	IndexId        int
+}
+
+func (node *ForeachStatement) Accept(visitor Visitor) {
+	visitor.VisitForeach(node)
+}
+
+func (node *ForeachStatement) Begin() tokenizer.SourceLocation {
+	return node.foreachKeyword.At
+}
+
+func (node *ForeachStatement) End() tokenizer.SourceLocation {
+	return node.Body.End()
+}
+
+// An arm of a match runs when the matched value is the variant it names.
+// Arms of a match statement have a body and arms of a match expression have
+// a value. Type is the structure of the variant
+type MatchArm struct {
+	Variant *tokenizer.Token
+	Body    *Body
+	Value   Expression
+	Type    *StructureTypeType
+}
+
+// Runs the arm for the variant of a union value. A matched variable has the
+// type of the variant inside of the arm
+type MatchStatement struct {
+	matchKeyword *tokenizer.Token
+	Subject      Expression
+	Arms         []*MatchArm
+	close        *tokenizer.Token
+}
+
+func (node *MatchStatement) Accept(visitor Visitor) {
+	visitor.VisitMatch(node)
+}
+
+func (node *MatchStatement) Begin() tokenizer.SourceLocation {
+	return node.matchKeyword.At
+}
+
+func (node *MatchStatement) End() tokenizer.SourceLocation {
+	return node.close.End()
+}
+
+// Evaluates to the value of the arm for the variant of a union value
+type MatchExpression struct {
+	matchKeyword *tokenizer.Token
+	Subject      Expression
+	Arms         []*MatchArm
+	close        *tokenizer.Token
+	Type         TypeNode
+}
+
+func (node *MatchExpression) Accept(visitor Visitor) {
+	visitor.VisitMatchExpression(node)
+}
+
+func (node *MatchExpression) Begin() tokenizer.SourceLocation {
+	return node.matchKeyword.At
+}
+
+func (node *MatchExpression) End() tokenizer.SourceLocation {
+	return node.close.End()
+}
+
+func (node *MatchExpression) GetType() TypeNode {
+	return node.Type
+}
+
 type NamedType struct {
-	Token *tokenizer.Token
-	Type  TypeNode
+	Token         *tokenizer.Token
+	TypeArguments []TypeExpression
+	close         *tokenizer.Token
+	Type          TypeNode
 }
 
 func (node *NamedType) Accept(visitor Visitor) {
@@ -300,6 +476,10 @@ func (node *NamedType) Begin() tokenizer.SourceLocation {
 }
 
 func (node *NamedType) End() tokenizer.SourceLocation {
+	if node.close != nil {
+		return node.close.End()
+	}
+
 	return node.Token.End()
 }
 
@@ -308,10 +488,12 @@ func (node *NamedType) GetType() TypeNode {
 }
 
 type StructureNamedEntry struct {
-	Name     *tokenizer.Token
-	TypeExp  TypeExpression
-	Type     *StructureNamedEntryType
-	UniqueId int
+	Name      *tokenizer.Token
+	TypeExp   TypeExpression
+	Type      *StructureNamedEntryType
+	UniqueId  int
+	IsMutable bool
+	IsPublic  bool
 }
 
 func (node *StructureNamedEntry) Begin() tokenizer.SourceLocation {
@@ -349,6 +531,39 @@ func (node *StructureType) GetType() TypeNode {
 	return node.Type
 }
 
+// A variant without fields has a nil TypeExp
+type UnionVariant struct {
+	Name    *tokenizer.Token
+	TypeExp TypeExpression
+}
+
+type UnionType struct {
+	Variants []*UnionVariant
+	Type     *UnionTypeType
+}
+
+func (node *UnionType) Accept(visitor Visitor) {
+	visitor.VisitUnionType(node)
+}
+
+func (node *UnionType) Begin() tokenizer.SourceLocation {
+	return node.Variants[0].Name.At
+}
+
+func (node *UnionType) End() tokenizer.SourceLocation {
+	var last = node.Variants[len(node.Variants)-1]
+
+	if last.TypeExp != nil {
+		return last.TypeExp.End()
+	}
+
+	return last.Name.End()
+}
+
+func (node *UnionType) GetType() TypeNode {
+	return node.Type
+}
+
 type FunctionType struct {
 	Input  TypeExpression
 	Output TypeExpression
@@ -371,6 +586,29 @@ func (node *FunctionType) GetType() TypeNode {
 	return node.Type
 }
 
+type ArrayType struct {
+	open        *tokenizer.Token
+	ElementType TypeExpression
+	IsVariadic  bool
+	Type        *ArrayTypeType
+}
+
+func (node *ArrayType) Accept(visitor Visitor) {
+	visitor.VisitArrayType(node)
+}
+
+func (node *ArrayType) Begin() tokenizer.SourceLocation {
+	return node.open.At
+}
+
+func (node *ArrayType) End() tokenizer.SourceLocation {
+	return node.ElementType.End()
+}
+
+func (node *ArrayType) GetType() TypeNode {
+	return node.Type
+}
+
 type WhereType struct {
 	whereKeyword *tokenizer.Token
 	TypeExp      TypeExpression
@@ -398,12 +636,19 @@ type AsNamingType struct {
 	Name    *tokenizer.Token
 }
 
+type TypeParameter struct {
+	Name *tokenizer.Token
+	Type *TypeParameterType
+}
+
 type TypeDefinition struct {
-	typeKeyword *tokenizer.Token
-	Name        *tokenizer.Token
-	TypeExp     TypeExpression
-	Scope       *Scope
-	Type        TypeNode
+	typeKeyword    *tokenizer.Token
+	Name           *tokenizer.Token
+	TypeParameters []*TypeParameter
+	TypeExp        TypeExpression
+	Scope          *Scope
+	Type           TypeNode
+	IsPublic       bool
 }
 
 func (node *TypeDefinition) Accept(visitor Visitor) {
@@ -446,14 +691,20 @@ func (node *Function) End() tokenizer.SourceLocation {
 }
 
 type FunctionDefinition struct {
-	Name     *tokenizer.Token
-	Function *Function
+	Name           *tokenizer.Token
+	TypeParameters []*TypeParameter
+	Function       *Function
+	IsPublic       bool
 }
 
 func (node *FunctionDefinition) Accept(visitor Visitor) {
 	visitor.VisitFnDef(node)
 }
 
+func (node *FunctionDefinition) GetType() TypeNode {
+	return node.Function.Type
+}
+
 func (node *FunctionDefinition) Begin() tokenizer.SourceLocation {
 	return node.Name.At
 }
@@ -462,8 +713,66 @@ func (node *FunctionDefinition) End() tokenizer.SourceLocation {
 	return node.Function.End()
 }
 
+// A named integer known at compile time. TypeExp and WhereExp are nil when
+// they aren't given
+type ConstantDefinition struct {
+	constKeyword *tokenizer.Token
+	Name         *tokenizer.Token
+	TypeExp      TypeExpression
+	Value        Expression
+	WhereExp     Expression
+	Type         TypeNode
+	IsPublic     bool
+}
+
+func (node *ConstantDefinition) Accept(visitor Visitor) {
+	visitor.VisitConst(node)
+}
+
+func (node *ConstantDefinition) GetType() TypeNode {
+	return node.Type
+}
+
+func (node *ConstantDefinition) Begin() tokenizer.SourceLocation {
+	return node.constKeyword.At
+}
+
+func (node *ConstantDefinition) End() tokenizer.SourceLocation {
+	if node.WhereExp != nil {
+		return node.WhereExp.End()
+	}
+
+	return node.Value.End()
+}
+
+// The name of a module such as geometry.shapes given after module or import
+type ModulePath struct {
+	keyword *tokenizer.Token
+	Names   []*tokenizer.Token
+}
+
+func (path *ModulePath) Name() string {
+	var names []string = nil
+
+	for _, name := range path.Names {
+		names = append(names, name.Value)
+	}
+
+	return strings.Join(names, ".")
+}
+
+func (path *ModulePath) Begin() tokenizer.SourceLocation {
+	return path.keyword.At
+}
+
+func (path *ModulePath) End() tokenizer.SourceLocation {
+	return path.Names[len(path.Names)-1].End()
+}
+
 type FileDefinition struct {
 	start       tokenizer.SourceLocation
+	Module      *ModulePath
+	Imports     []*ModulePath
 	Definitions []Definition
 	Scope       *Scope
 	end         tokenizer.SourceLocation
@@ -506,3 +815,150 @@ func (node *ReturnStatement) End() tokenizer.SourceLocation {
 		return node.returnKeyword.End()
 	}
 }
+
+type VariableDefinition struct {
+	keyword   *tokenizer.Token
+	Name      *tokenizer.Token
+	TypeExp   TypeExpression
+	Value     Expression
+	IsMutable bool
+	UniqueId  int
+	Type      TypeNode
+}
+
+func (node *VariableDefinition) Accept(visitor Visitor) {
+	visitor.VisitVarDef(node)
+}
+
+func (node *VariableDefinition) GetType() TypeNode {
+	return node.Type
+}
+
+func (node *VariableDefinition) Begin() tokenizer.SourceLocation {
+	return node.keyword.At
+}
+
+func (node *VariableDefinition) End() tokenizer.SourceLocation {
+	if node.Value != nil {
+		return node.Value.End()
+	} else if node.TypeExp != nil {
+		return node.TypeExp.End()
+	} else {
+		return node.Name.End()
+	}
+}
+
+// Binds the entries of a structure value to new variables by position
+type DestructuringDefinition struct {
+	keyword   *tokenizer.Token
+	Names     []*tokenizer.Token
+	close     *tokenizer.Token
+	Value     Expression
+	IsMutable bool
+	UniqueIds []int
+	Types     []TypeNode
+}
+
+func (node *DestructuringDefinition) Accept(visitor Visitor) {
+	visitor.VisitDestructure(node)
+}
+
+func (node *DestructuringDefinition) Begin() tokenizer.SourceLocation {
+	return node.keyword.At
+}
+
+func (node *DestructuringDefinition) End() tokenizer.SourceLocation {
+	return node.Value.End()
+}
+
+type AssignmentStatement struct {
+	Target Expression
+	assign *tokenizer.Token
+	Value  Expression
+}
+
+func (node *AssignmentStatement) Accept(visitor Visitor) {
+	visitor.VisitAssignment(node)
+}
+
+func (node *AssignmentStatement) Begin() tokenizer.SourceLocation {
+	return node.Target.Begin()
+}
+
+func (node *AssignmentStatement) End() tokenizer.SourceLocation {
+	return node.Value.End()
+}
+
+// An assert has to be proven before it is known. An assumption is known
+// without a proof
+type AssertStatement struct {
+	keyword      *tokenizer.Token
+	Expression   Expression
+	IsAssumption bool
+}
+
+func (node *AssertStatement) Accept(visitor Visitor) {
+	visitor.VisitAssert(node)
+}
+
+func (node *AssertStatement) Begin() tokenizer.SourceLocation {
+	return node.keyword.At
+}
+
+func (node *AssertStatement) End() tokenizer.SourceLocation {
+	return node.Expression.End()
+}
+
+type UnreachableStatement struct {
+	keyword *tokenizer.Token
+}
+
+func (node *UnreachableStatement) Accept(visitor Visitor) {
+	visitor.VisitUnreachable(node)
+}
+
+func (node *UnreachableStatement) Begin() tokenizer.SourceLocation {
+	return node.keyword.At
+}
+
+func (node *UnreachableStatement) End() tokenizer.SourceLocation {
+	return node.keyword.End()
+}
+
+// Whether the end of a statement can be reached from its start. A return
+// inside of a loop doesn't count since the loop can end before reaching it
+func CanFallThrough(statement ParseNode) bool {
+	if _, ok := statement.(*ReturnStatement); ok {
+		return false
+	}
+
+	if _, ok := statement.(*UnreachableStatement); ok {
+		return false
+	}
+
+	if asBody, ok := statement.(*Body); ok {
+		for _, subStatement := range asBody.Statements {
+			if !CanFallThrough(subStatement) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	if asIf, ok := statement.(*IfStatement); ok {
+		return asIf.ElseBody == nil || CanFallThrough(asIf.Body) || CanFallThrough(asIf.ElseBody)
+	}
+
+	if asMatch, ok := statement.(*MatchStatement); ok {
+		for _, arm := range asMatch.Arms {
+			if CanFallThrough(arm.Body) {
+				return true
+			}
+		}
+
+		return len(asMatch.Arms) == 0
+	}
+
+	return true
+}
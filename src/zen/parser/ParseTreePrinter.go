@@ -0,0 +1,362 @@
+package parser
+
+import (
+	"strings"
+)
+
+type treePrinter struct {
+	builder strings.Builder
+	depth   int
+}
+
+func PrintTree(node ParseNode) string {
+	var printer = &treePrinter{}
+	node.Accept(printer)
+	return printer.builder.String()
+}
+
+func (printer *treePrinter) writeLine(text string) {
+	printer.builder.WriteString(strings.Repeat("  ", printer.depth))
+	printer.builder.WriteString(text)
+	printer.builder.WriteString("\n")
+}
+
+func (printer *treePrinter) child(node ParseNode) {
+	printer.depth = printer.depth + 1
+
+	if node == nil {
+		printer.writeLine("<nil>")
+	} else {
+		node.Accept(printer)
+	}
+
+	printer.depth = printer.depth - 1
+}
+
+func (printer *treePrinter) VisitVoidExpression(expr *VoidExpression) {
+	printer.writeLine("Void")
+}
+
+func (printer *treePrinter) VisitIdentifier(id *Identifier) {
+	printer.writeLine("Identifier " + id.Token.Value)
+}
+
+func (printer *treePrinter) VisitNumber(number *Number) {
+	printer.writeLine("Number " + number.Token.Value)
+}
+
+func (printer *treePrinter) VisitUnaryExpression(exp *UnaryExpression) {
+	printer.writeLine("Unary " + exp.Operator.Value)
+	printer.child(exp.Expr)
+}
+
+func (printer *treePrinter) VisitPropertyExpression(exp *PropertyExpression) {
+	if exp.Property == nil {
+		printer.writeLine("Property")
+	} else {
+		printer.writeLine("Property " + exp.Property.Value)
+	}
+	printer.child(exp.Left)
+}
+
+func (printer *treePrinter) VisitBinaryExpression(exp *BinaryExpression) {
+	printer.writeLine("Binary " + exp.Operator.Value)
+	printer.child(exp.Left)
+	printer.child(exp.Right)
+}
+
+func (printer *treePrinter) VisitStructureExpression(exp *StructureExpression) {
+	printer.writeLine("Structure")
+
+	printer.depth = printer.depth + 1
+	for _, entry := range exp.Entries {
+		if entry.Name != nil {
+			printer.writeLine("Entry " + entry.Name.Value)
+		} else {
+			printer.writeLine("Entry")
+		}
+		printer.child(entry.Expr)
+	}
+	printer.depth = printer.depth - 1
+}
+
+func (printer *treePrinter) VisitCallExpression(exp *CallExpression) {
+	printer.writeLine("Call")
+	printer.child(exp.Function)
+
+	for _, argument := range exp.Arguments {
+		printer.child(argument)
+	}
+}
+
+func (printer *treePrinter) VisitIndexExpression(exp *IndexExpression) {
+	printer.writeLine("Index")
+	printer.child(exp.Left)
+	printer.child(exp.Index)
+}
+
+func (printer *treePrinter) VisitFunction(function *Function) {
+	printer.writeLine("Function")
+	printer.child(function.TypeExp)
+	printer.child(function.Body)
+}
+
+func (printer *treePrinter) VisitIf(ifStatement *IfStatement) {
+	printer.writeLine("If")
+	printer.child(ifStatement.Expresssion)
+	printer.child(ifStatement.Body)
+
+	if ifStatement.ElseBody != nil {
+		printer.writeLine("Else")
+		printer.child(ifStatement.ElseBody)
+	}
+}
+
+func (printer *treePrinter) VisitWhile(whileStatement *WhileStatement) {
+	printer.writeLine("While")
+	printer.child(whileStatement.Condition)
+
+	if whileStatement.Invariant != nil {
+		printer.writeLine("Invariant")
+		printer.child(whileStatement.Invariant)
+	}
+
+	if whileStatement.Decreases != nil {
+		printer.writeLine("Decreases")
+		printer.child(whileStatement.Decreases)
+	}
+
+	printer.child(whileStatement.Body)
+}
+
+func (printer *treePrinter) VisitForeach(foreach *ForeachStatement) {
+	if foreach.Index != nil {
+		printer.writeLine("Foreach " + foreach.Element.Value + ", " + foreach.Index.Value)
+	} else {
+		printer.writeLine("Foreach " + foreach.Element.Value)
+	}
+
+	printer.child(foreach.Collection)
+	printer.child(foreach.Body)
+}
+
+func (printer *treePrinter) VisitMatch(match *MatchStatement) {
+	printer.writeLine("Match")
+	printer.child(match.Subject)
+
+	printer.depth = printer.depth + 1
+	for _, arm := range match.Arms {
+		printer.writeLine("Arm " + arm.Variant.Value)
+		printer.child(arm.Body)
+	}
+	printer.depth = printer.depth - 1
+}
+
+func (printer *treePrinter) VisitMatchExpression(match *MatchExpression) {
+	printer.writeLine("MatchExpression")
+	printer.child(match.Subject)
+
+	printer.depth = printer.depth + 1
+	for _, arm := range match.Arms {
+		printer.writeLine("Arm " + arm.Variant.Value)
+		printer.child(arm.Value)
+	}
+	printer.depth = printer.depth - 1
+}
+
+func (printer *treePrinter) VisitBody(body *Body) {
+	printer.writeLine("Body")
+
+	for _, statement := range body.Statements {
+		printer.child(statement)
+	}
+}
+
+func (printer *treePrinter) VisitReturn(ret *ReturnStatement) {
+	printer.writeLine("Return")
+
+	for _, expression := range ret.ExpressionList {
+		printer.child(expression)
+	}
+}
+
+func (printer *treePrinter) VisitVarDef(varDef *VariableDefinition) {
+	if varDef.IsMutable {
+		printer.writeLine("Var " + varDef.Name.Value)
+	} else {
+		printer.writeLine("Let " + varDef.Name.Value)
+	}
+
+	if varDef.TypeExp != nil {
+		printer.child(varDef.TypeExp)
+	}
+
+	if varDef.Value != nil {
+		printer.child(varDef.Value)
+	}
+}
+
+func (printer *treePrinter) VisitDestructure(destructure *DestructuringDefinition) {
+	var names = ""
+
+	for index, name := range destructure.Names {
+		if index > 0 {
+			names = names + ", "
+		}
+
+		names = names + name.Value
+	}
+
+	if destructure.IsMutable {
+		printer.writeLine("Var [" + names + "]")
+	} else {
+		printer.writeLine("Let [" + names + "]")
+	}
+
+	printer.child(destructure.Value)
+}
+
+func (printer *treePrinter) VisitAssignment(assignment *AssignmentStatement) {
+	printer.writeLine("Assign")
+	printer.child(assignment.Target)
+	printer.child(assignment.Value)
+}
+
+func (printer *treePrinter) VisitAssert(assert *AssertStatement) {
+	if assert.IsAssumption {
+		printer.writeLine("Assume")
+	} else {
+		printer.writeLine("Assert")
+	}
+
+	printer.child(assert.Expression)
+}
+
+func (printer *treePrinter) VisitUnreachable(unreachable *UnreachableStatement) {
+	printer.writeLine("Unreachable")
+}
+
+func (printer *treePrinter) VisitNamedType(namedType *NamedType) {
+	printer.writeLine("NamedType " + namedType.Token.Value)
+
+	for _, typeArgument := range namedType.TypeArguments {
+		printer.child(typeArgument)
+	}
+}
+
+func (printer *treePrinter) VisitStructureType(structure *StructureType) {
+	printer.writeLine("StructureType")
+
+	printer.depth = printer.depth + 1
+	for _, entry := range structure.Entries {
+		if entry.Name != nil && entry.IsMutable {
+			printer.writeLine("Entry " + publicPrefix(entry.IsPublic) + "mut " + entry.Name.Value)
+		} else if entry.Name != nil {
+			printer.writeLine("Entry " + publicPrefix(entry.IsPublic) + entry.Name.Value)
+		} else {
+			printer.writeLine("Entry")
+		}
+		printer.child(entry.TypeExp)
+	}
+	printer.depth = printer.depth - 1
+}
+
+func (printer *treePrinter) VisitFunctionType(fn *FunctionType) {
+	printer.writeLine("FunctionType")
+	printer.child(fn.Input)
+	printer.child(fn.Output)
+}
+
+func (printer *treePrinter) VisitArrayType(array *ArrayType) {
+	if array.IsVariadic {
+		printer.writeLine("ArrayType ...")
+	} else {
+		printer.writeLine("ArrayType")
+	}
+	printer.child(array.ElementType)
+}
+
+func (printer *treePrinter) VisitUnionType(union *UnionType) {
+	printer.writeLine("UnionType")
+
+	printer.depth = printer.depth + 1
+	for _, variant := range union.Variants {
+		printer.writeLine("Variant " + variant.Name.Value)
+
+		if variant.TypeExp != nil {
+			printer.child(variant.TypeExp)
+		}
+	}
+	printer.depth = printer.depth - 1
+}
+
+func (printer *treePrinter) VisitWhereType(where *WhereType) {
+	printer.writeLine("Where")
+	printer.child(where.TypeExp)
+	printer.child(where.WhereExp)
+}
+
+func typeParameterNames(typeParameters []*TypeParameter) string {
+	if len(typeParameters) == 0 {
+		return ""
+	}
+
+	var names []string = nil
+
+	for _, typeParameter := range typeParameters {
+		names = append(names, typeParameter.Name.Value)
+	}
+
+	return "(" + strings.Join(names, ", ") + ")"
+}
+
+func publicPrefix(isPublic bool) string {
+	if isPublic {
+		return "pub "
+	} else {
+		return ""
+	}
+}
+
+func (printer *treePrinter) VisitTypeDef(typeDef *TypeDefinition) {
+	printer.writeLine("TypeDefinition " + publicPrefix(typeDef.IsPublic) + typeDef.Name.Value + typeParameterNames(typeDef.TypeParameters))
+	printer.child(typeDef.TypeExp)
+}
+
+func (printer *treePrinter) VisitFnDef(fnDef *FunctionDefinition) {
+	printer.writeLine("FunctionDefinition " + publicPrefix(fnDef.IsPublic) + fnDef.Name.Value + typeParameterNames(fnDef.TypeParameters))
+	printer.child(fnDef.Function)
+}
+
+func (printer *treePrinter) VisitConst(constDef *ConstantDefinition) {
+	printer.writeLine("ConstantDefinition " + publicPrefix(constDef.IsPublic) + constDef.Name.Value)
+
+	if constDef.TypeExp != nil {
+		printer.child(constDef.TypeExp)
+	}
+
+	printer.child(constDef.Value)
+
+	if constDef.WhereExp != nil {
+		printer.child(constDef.WhereExp)
+	}
+}
+
+func (printer *treePrinter) VisitFile(fileDef *FileDefinition) {
+	printer.writeLine("File")
+	printer.depth = printer.depth + 1
+
+	if fileDef.Module != nil {
+		printer.writeLine("Module " + fileDef.Module.Name())
+	}
+
+	for _, importPath := range fileDef.Imports {
+		printer.writeLine("Import " + importPath.Name())
+	}
+
+	printer.depth = printer.depth - 1
+
+	for _, definition := range fileDef.Definitions {
+		printer.child(definition)
+	}
+}
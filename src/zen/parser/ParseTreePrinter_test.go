@@ -0,0 +1,37 @@
+package parser
+
+import (
+	"testing"
+	"zen/source"
+)
+
+func TestPrintTree(t *testing.T) {
+	var fileDef, errors = Parse(source.SourceFromString("func Min[a: i32, b: i32] => [r: i32] { return a }"))
+
+	if len(errors) != 0 {
+		t.Fatal("Unexpected parse errors")
+	}
+
+	var expected = `File
+  FunctionDefinition Min
+    Function
+      FunctionType
+        StructureType
+          Entry a
+            NamedType i32
+          Entry b
+            NamedType i32
+        StructureType
+          Entry r
+            NamedType i32
+      Body
+        Return
+          Identifier a
+`
+
+	var result = PrintTree(fileDef)
+
+	if result != expected {
+		t.Errorf("Expected tree\n%s\ngot\n%s", expected, result)
+	}
+}
@@ -8,6 +8,7 @@ import (
 type parseState struct {
 	tokens   *tokenizer.TokenizeResult
 	location uint
+	inWhere  bool
 }
 
 type parseResult struct {
@@ -18,6 +19,7 @@ func createState(tokens *tokenizer.TokenizeResult) (result parseState) {
 	return parseState{
 		tokens,
 		0,
+		false,
 	}
 }
 
@@ -88,6 +90,7 @@ func parseIdentifier(parseResult *parseResult, state *parseState) (result *Ident
 		return &Identifier{
 			maybeToken,
 			&UndefinedType{},
+			nil,
 		}, true
 	} else {
 		return nil, false
@@ -161,12 +164,44 @@ const (
 func parseSingleType(parseResult *parseResult, state *parseState) (result TypeExpression, okResult bool) {
 	var next = peek(state, 0)
 
-	if next.TokenType == tokenizer.OpenSqaureToken {
+	if next.TokenType == tokenizer.OpenSqaureToken && isArrayTypeStart(state) {
+		return parseArrayType(parseResult, state)
+	} else if next.TokenType == tokenizer.EllipsisToken {
+		return parseArrayType(parseResult, state)
+	} else if next.TokenType == tokenizer.OpenSqaureToken {
 		result = parseStructureType(parseResult, state)
 	} else if next.TokenType == tokenizer.IDToken {
 		advance(state)
+
+		var typeArguments []TypeExpression = nil
+		var closeParen *tokenizer.Token = nil
+
+		if optional(state, tokenizer.OpenParenToken) != nil {
+			var hasNext = peek(state, 0).TokenType != tokenizer.CloseParenToken
+
+			for hasNext {
+				typeArgument, ok := parseType(parseResult, state)
+
+				if !ok {
+					return nil, false
+				}
+
+				typeArguments = append(typeArguments, typeArgument)
+
+				hasNext = checkHasNext(state, tokenizer.CloseParenToken)
+			}
+
+			closeParen = expect(parseResult, state, tokenizer.CloseParenToken)
+
+			if closeParen == nil {
+				return nil, false
+			}
+		}
+
 		result = &NamedType{
 			next,
+			typeArguments,
+			closeParen,
 			&UndefinedType{},
 		}
 	} else if next.TokenType == tokenizer.OpenParenToken {
@@ -191,6 +226,51 @@ func parseSingleType(parseResult *parseResult, state *parseState) (result TypeEx
 	return result, true
 }
 
+// An empty structure type is also written as [] so an array type is only
+// recognized when another type follows the brackets
+func isArrayTypeStart(state *parseState) bool {
+	if peek(state, 1).TokenType != tokenizer.CloseSquareToken {
+		return false
+	}
+
+	var elementStart = peek(state, 2).TokenType
+
+	if getTypeOperatorPrecedence(peek(state, 2)) != noTypePrecedence {
+		return false
+	}
+
+	return elementStart == tokenizer.IDToken ||
+		elementStart == tokenizer.OpenSqaureToken ||
+		elementStart == tokenizer.OpenParenToken ||
+		elementStart == tokenizer.EllipsisToken
+}
+
+func parseArrayType(parseResult *parseResult, state *parseState) (result TypeExpression, okResult bool) {
+	var open = optional(state, tokenizer.EllipsisToken)
+	var isVariadic = open != nil
+
+	if !isVariadic {
+		open = expect(parseResult, state, tokenizer.OpenSqaureToken)
+
+		if open == nil || expect(parseResult, state, tokenizer.CloseSquareToken) == nil {
+			return nil, false
+		}
+	}
+
+	elementType, ok := parseSingleType(parseResult, state)
+
+	if !ok {
+		return nil, false
+	}
+
+	return &ArrayType{
+		open,
+		elementType,
+		isVariadic,
+		nil,
+	}, true
+}
+
 func parseUnaryType(parseResult *parseResult, state *parseState) (result TypeExpression, okResult bool) {
 	return parseSingleType(parseResult, state)
 }
@@ -236,7 +316,10 @@ func parseBinaryType(parseResult *parseResult, state *parseState, precedence typ
 				nil,
 			}
 		case "where":
+			var wasInWhere = state.inWhere
+			state.inWhere = true
 			right, ok := parseExpression(parseResult, state)
+			state.inWhere = wasInWhere
 
 			if !ok {
 				return nil, false
@@ -268,6 +351,26 @@ func checkHasNext(state *parseState, closingToken tokenizer.TokenType) (result b
 	}
 }
 
+func isMutableEntry(state *parseState) bool {
+	return peek(state, 0).TokenType == tokenizer.IDToken &&
+		peek(state, 0).Value == "mut" &&
+		peek(state, 1).TokenType == tokenizer.IDToken
+}
+
+func isPublicEntry(state *parseState) bool {
+	return peek(state, 0).TokenType == tokenizer.IDToken &&
+		peek(state, 0).Value == "pub" &&
+		peek(state, 1).TokenType == tokenizer.IDToken
+}
+
+func isNamedEntryStart(state *parseState) bool {
+	if isMutableEntry(state) || isPublicEntry(state) {
+		return true
+	}
+
+	return peek(state, 0).TokenType == tokenizer.IDToken && peek(state, 1).TokenType == tokenizer.ColonToken
+}
+
 func parseStructureType(parseResult *parseResult, state *parseState) (result *StructureType) {
 	openToken := expect(parseResult, state, tokenizer.OpenSqaureToken)
 	if openToken == nil {
@@ -279,7 +382,7 @@ func parseStructureType(parseResult *parseResult, state *parseState) (result *St
 	var hasNext = peek(state, 0).TokenType != tokenizer.CloseSquareToken
 
 	for hasNext {
-		if peek(state, 0).TokenType == tokenizer.IDToken && peek(state, 1).TokenType == tokenizer.ColonToken {
+		if isNamedEntryStart(state) {
 			hasNext = false
 		} else {
 			typeExp, ok := parseType(parseResult, state)
@@ -288,7 +391,7 @@ func parseStructureType(parseResult *parseResult, state *parseState) (result *St
 				return nil
 			}
 
-			entries = append(entries, &StructureNamedEntry{nil, typeExp, nil, getNextTypeId()})
+			entries = append(entries, &StructureNamedEntry{nil, typeExp, nil, getNextTypeId(), false, false})
 
 			hasNext = checkHasNext(state, tokenizer.CloseSquareToken)
 		}
@@ -297,6 +400,18 @@ func parseStructureType(parseResult *parseResult, state *parseState) (result *St
 	hasNext = peek(state, 0).TokenType != tokenizer.CloseSquareToken
 
 	for hasNext {
+		var isPublic = isPublicEntry(state)
+
+		if isPublic {
+			advance(state)
+		}
+
+		var isMutable = isMutableEntry(state)
+
+		if isMutable {
+			advance(state)
+		}
+
 		var name = expect(parseResult, state, tokenizer.IDToken)
 
 		if name == nil {
@@ -313,7 +428,7 @@ func parseStructureType(parseResult *parseResult, state *parseState) (result *St
 			return nil
 		}
 
-		entries = append(entries, &StructureNamedEntry{name, typeExp, nil, getNextTypeId()})
+		entries = append(entries, &StructureNamedEntry{name, typeExp, nil, getNextTypeId(), isMutable, isPublic})
 
 		hasNext = checkHasNext(state, tokenizer.CloseSquareToken)
 	}
@@ -332,6 +447,88 @@ func parseStructureType(parseResult *parseResult, state *parseState) (result *St
 	}
 }
 
+// A parenthesized list of names after the name of a definition declares type
+// parameters unless it is a parenthesized type, such as (Input) => Output
+func isTypeParameterListStart(state *parseState) bool {
+	if peek(state, 0).TokenType != tokenizer.OpenParenToken {
+		return false
+	}
+
+	var offset uint = 1
+
+	for peek(state, offset).TokenType == tokenizer.IDToken {
+		var next = peek(state, offset+1).TokenType
+
+		if next == tokenizer.CloseParenToken {
+			return getTypeOperatorPrecedence(peek(state, offset+2)) == noTypePrecedence
+		} else if next != tokenizer.CommaToken {
+			return false
+		}
+
+		offset = offset + 2
+	}
+
+	return false
+}
+
+func parseTypeParameters(parseResult *parseResult, state *parseState) (result []*TypeParameter, okResult bool) {
+	if !isTypeParameterListStart(state) {
+		return nil, true
+	}
+
+	advance(state)
+
+	var hasNext = true
+
+	for hasNext {
+		var name = expect(parseResult, state, tokenizer.IDToken)
+
+		if name == nil {
+			return nil, false
+		}
+
+		result = append(result, &TypeParameter{name, nil})
+
+		hasNext = checkHasNext(state, tokenizer.CloseParenToken)
+	}
+
+	if expect(parseResult, state, tokenizer.CloseParenToken) == nil {
+		return nil, false
+	}
+
+	return result, true
+}
+
+// Circle[r: i32] where r > 0 | Empty
+func parseUnionType(parseResult *parseResult, state *parseState) (result *UnionType, okResult bool) {
+	var variants []*UnionVariant = nil
+	var hasNext = true
+
+	for hasNext {
+		var name = expect(parseResult, state, tokenizer.IDToken)
+
+		if name == nil {
+			return nil, false
+		}
+
+		var typeExp TypeExpression = nil
+
+		if peek(state, 0).TokenType == tokenizer.OpenSqaureToken {
+			var ok bool
+			typeExp, ok = parseType(parseResult, state)
+
+			if !ok {
+				return nil, false
+			}
+		}
+
+		variants = append(variants, &UnionVariant{name, typeExp})
+		hasNext = optional(state, tokenizer.BitwiseOrToken) != nil
+	}
+
+	return &UnionType{variants, nil}, true
+}
+
 func parseTypeDefinition(parseResult *parseResult, state *parseState) (result *TypeDefinition) {
 	openToken := expectIdentifier(parseResult, state, "type")
 	if openToken == nil {
@@ -341,7 +538,19 @@ func parseTypeDefinition(parseResult *parseResult, state *parseState) (result *T
 	if name == nil {
 		return nil
 	}
-	typeExp, ok := parseType(parseResult, state)
+	typeParameters, ok := parseTypeParameters(parseResult, state)
+
+	if !ok {
+		return nil
+	}
+
+	var typeExp TypeExpression
+
+	if optional(state, tokenizer.AssignToken) != nil {
+		typeExp, ok = parseUnionType(parseResult, state)
+	} else {
+		typeExp, ok = parseType(parseResult, state)
+	}
 
 	if !ok {
 		return nil
@@ -350,9 +559,11 @@ func parseTypeDefinition(parseResult *parseResult, state *parseState) (result *T
 	return &TypeDefinition{
 		openToken,
 		name,
+		typeParameters,
 		typeExp,
 		CreateScope(),
 		&UndefinedType{},
+		false,
 	}
 }
 
@@ -385,10 +596,18 @@ func getExpressionOperatorPrecedence(token *tokenizer.Token) (result expressionO
 		return comparePrecedence
 	case tokenizer.AddToken:
 		fallthrough
+	case tokenizer.WrapAddToken:
+		fallthrough
+	case tokenizer.WrapMinusToken:
+		fallthrough
 	case tokenizer.MinusToken:
 		return addExpPrecedence
 	case tokenizer.MultiplyToken:
 		fallthrough
+	case tokenizer.WrapMultiplyToken:
+		fallthrough
+	case tokenizer.ModToken:
+		fallthrough
 	case tokenizer.DivideToken:
 		return multiplyExpPrecedence
 	case tokenizer.BooleanOrToken:
@@ -401,7 +620,76 @@ func getExpressionOperatorPrecedence(token *tokenizer.Token) (result expressionO
 }
 
 func isPostfixOperator(token *tokenizer.Token) bool {
-	return token.TokenType == tokenizer.DotToken
+	return token.TokenType == tokenizer.DotToken ||
+		token.TokenType == tokenizer.OpenParenToken ||
+		token.TokenType == tokenizer.OpenSqaureToken
+}
+
+func parseIndexExpression(parseResult *parseResult, state *parseState, left Expression) (result *IndexExpression, okResult bool) {
+	var open = expect(parseResult, state, tokenizer.OpenSqaureToken)
+
+	if open == nil {
+		return nil, false
+	}
+
+	index, ok := parseExpression(parseResult, state)
+
+	if !ok {
+		return nil, false
+	}
+
+	var close = expect(parseResult, state, tokenizer.CloseSquareToken)
+
+	if close == nil {
+		return nil, false
+	}
+
+	return &IndexExpression{
+		left,
+		open,
+		index,
+		close,
+		&UndefinedType{},
+	}, true
+}
+
+func parseCallExpression(parseResult *parseResult, state *parseState, function Expression) (result *CallExpression, okResult bool) {
+	var openParen = expect(parseResult, state, tokenizer.OpenParenToken)
+
+	if openParen == nil {
+		return nil, false
+	}
+
+	var arguments []Expression = nil
+
+	var hasNext = peek(state, 0).TokenType != tokenizer.CloseParenToken
+
+	for hasNext {
+		argument, ok := parseExpression(parseResult, state)
+
+		if !ok {
+			return nil, false
+		}
+
+		arguments = append(arguments, argument)
+
+		hasNext = checkHasNext(state, tokenizer.CloseParenToken)
+	}
+
+	var closeParen = expect(parseResult, state, tokenizer.CloseParenToken)
+
+	if closeParen == nil {
+		return nil, false
+	}
+
+	return &CallExpression{
+		function,
+		openParen,
+		arguments,
+		closeParen,
+		&UndefinedType{},
+		nil,
+	}, true
 }
 
 func parsePostfixExpression(parseResult *parseResult, state *parseState) (result Expression, okResult bool) {
@@ -422,6 +710,18 @@ func parsePostfixExpression(parseResult *parseResult, state *parseState) (result
 				propertyName,
 				&UndefinedType{},
 			}
+		} else if next.TokenType == tokenizer.OpenParenToken {
+			result, ok = parseCallExpression(parseResult, state, result)
+
+			if !ok {
+				return nil, false
+			}
+		} else if next.TokenType == tokenizer.OpenSqaureToken {
+			result, ok = parseIndexExpression(parseResult, state, result)
+
+			if !ok {
+				return nil, false
+			}
 		} else {
 			parseResult.errors = append(parseResult.errors, CreateError(next.At, "Unkown postfix operator '"+next.Value+"'"))
 			return result, false
@@ -437,6 +737,10 @@ func parseSingleExpression(parseResult *parseResult, state *parseState) (result
 
 	if next.Value == "if" {
 		return parseIfStatement(parseResult, state)
+	} else if next.Value == "match" {
+		return parseMatchExpression(parseResult, state)
+	} else if next.TokenType == tokenizer.OpenSqaureToken && isLambdaStart(state) {
+		return parseFunction(parseResult, state)
 	} else if next.TokenType == tokenizer.IDToken {
 		return parseIdentifier(parseResult, state)
 	} else if next.TokenType == tokenizer.NumberToken {
@@ -455,13 +759,78 @@ func parseSingleExpression(parseResult *parseResult, state *parseState) (result
 	}
 }
 
+// A structure followed by => is the input of a function type so it starts a
+// lambda instead of a structure expression
+func isLambdaStart(state *parseState) bool {
+	var depth = 0
+
+	for offset := uint(0); peek(state, offset).TokenType != tokenizer.EOFToken; offset++ {
+		var tokenType = peek(state, offset).TokenType
+
+		if tokenType == tokenizer.OpenSqaureToken {
+			depth = depth + 1
+		} else if tokenType == tokenizer.CloseSquareToken {
+			depth = depth - 1
+
+			if depth == 0 {
+				return peek(state, offset+1).TokenType == tokenizer.FatArrowToken
+			}
+		}
+	}
+
+	return false
+}
+
+// post only marks a clause of a where expression. Anywhere else it is
+// an ordinary name
+func isClauseStart(state *parseState) bool {
+	if !state.inWhere || state.location == 0 {
+		return false
+	}
+
+	var previous = state.tokens.Tokens[state.location-1]
+
+	return previous.TokenType == tokenizer.BooleanAndToken ||
+		previous.TokenType == tokenizer.BooleanOrToken ||
+		(previous.TokenType == tokenizer.IDToken && previous.Value == "where")
+}
+
+func isPostMarker(state *parseState) bool {
+	var next = peek(state, 1).TokenType
+
+	return isClauseStart(state) &&
+		peek(state, 0).TokenType == tokenizer.IDToken &&
+		peek(state, 0).Value == "post" &&
+		(next == tokenizer.IDToken ||
+			next == tokenizer.NumberToken ||
+			next == tokenizer.OpenParenToken ||
+			next == tokenizer.MinusToken ||
+			next == tokenizer.NotToken)
+}
+
 func parseUnaryExpression(parseResult *parseResult, state *parseState) (result Expression, okResult bool) {
 	var maybeOperator = peek(state, 0)
 
-	if maybeOperator.TokenType == tokenizer.MinusToken {
+	if maybeOperator.TokenType == tokenizer.MinusToken || maybeOperator.TokenType == tokenizer.NotToken {
 		advance(state)
 		expr, ok := parseUnaryExpression(parseResult, state)
 
+		if !ok {
+			return nil, false
+		} else {
+			return &UnaryExpression{
+				expr,
+				maybeOperator,
+				&UndefinedType{},
+			}, true
+		}
+	} else if isPostMarker(state) {
+		advance(state)
+
+		// post applies to a whole comparison so it binds looser than
+		// everything except && and ||
+		expr, ok := parseBinaryExpression(parseResult, state, boolAndPrecedence)
+
 		if !ok {
 			return nil, false
 		} else {
@@ -513,66 +882,329 @@ func parseExpression(parseResult *parseResult, state *parseState) (result Expres
 	return parseBinaryExpression(parseResult, state, minExpressionPrecedence)
 }
 
-func parseStatement(parseResult *parseResult, state *parseState) (result Statement, okResult bool) {
-	var next = peek(state, 0)
-
-	if next.Value == "return" {
-		advance(state)
-
-		var after = peek(state, 0)
-
-		if after.TokenType == tokenizer.CloseCurlyToken || after.TokenType == tokenizer.SemicolonToken {
-			return &ReturnStatement{
-				next,
-				nil,
-				nil,
-			}, true
-		}
+func parseVariableDefinition(parseResult *parseResult, state *parseState) (result *VariableDefinition, okResult bool) {
+	var keyword = expect(parseResult, state, tokenizer.IDToken)
 
-		var expressions []Expression = nil
-		var hasNext = true
+	if keyword == nil {
+		return nil, false
+	}
 
-		for hasNext {
-			var returnValue, ok = parseExpression(parseResult, state)
-			expressions = append(expressions, returnValue)
-			hasNext = ok && optional(state, tokenizer.CommaToken) != nil
-		}
+	var name = expect(parseResult, state, tokenizer.IDToken)
 
-		return &ReturnStatement{
-			next,
-			expressions,
-			nil,
-		}, true
+	if name == nil {
+		return nil, false
 	}
 
-	return parseExpression(parseResult, state)
-}
+	var typeExp TypeExpression = nil
 
-func parseBody(parseResult *parseResult, state *parseState) (result *Body) {
-	openToken := expect(parseResult, state, tokenizer.OpenCurlyToken)
-	var statements []Statement = nil
+	if optional(state, tokenizer.ColonToken) != nil {
+		var ok bool
+		typeExp, ok = parseType(parseResult, state)
 
-	if openToken == nil {
-		return nil
+		if !ok {
+			return nil, false
+		}
 	}
 
-	closeToken := optional(state, tokenizer.CloseCurlyToken)
+	var value Expression = nil
+
+	if optional(state, tokenizer.AssignToken) != nil {
+		var ok bool
+		value, ok = parseExpression(parseResult, state)
 
-	for closeToken == nil && peek(state, 0).TokenType != tokenizer.EOFToken {
-		statement, ok := parseStatement(parseResult, state)
 		if !ok {
-			return nil
+			return nil, false
 		}
-		optional(state, tokenizer.SemicolonToken)
-		statements = append(statements, statement)
-		closeToken = optional(state, tokenizer.CloseCurlyToken)
+	} else if keyword.Value == "let" {
+		parseResult.errors = append(parseResult.errors, CreateError(peek(state, 0).At, "Expected '=' after let declaration"))
+		return nil, false
+	} else if typeExp == nil {
+		parseResult.errors = append(parseResult.errors, CreateError(peek(state, 0).At, "Expected type or value for variable '"+name.Value+"'"))
+		return nil, false
 	}
 
-	return &Body{
-		openToken,
-		statements,
+	return &VariableDefinition{
+		keyword,
+		name,
+		typeExp,
+		value,
+		keyword.Value == "var",
+		getNextTypeId(),
 		&UndefinedType{},
-		CreateScope(),
+	}, true
+}
+
+func parseDestructuringDefinition(parseResult *parseResult, state *parseState) (result *DestructuringDefinition, okResult bool) {
+	var keyword = expect(parseResult, state, tokenizer.IDToken)
+
+	if keyword == nil || expect(parseResult, state, tokenizer.OpenSqaureToken) == nil {
+		return nil, false
+	}
+
+	var names []*tokenizer.Token = nil
+	var uniqueIds []int = nil
+	var types []TypeNode = nil
+
+	for {
+		var name = expect(parseResult, state, tokenizer.IDToken)
+
+		if name == nil {
+			return nil, false
+		}
+
+		names = append(names, name)
+		uniqueIds = append(uniqueIds, getNextTypeId())
+		types = append(types, &UndefinedType{})
+
+		if optional(state, tokenizer.CommaToken) == nil {
+			break
+		}
+	}
+
+	var close = expect(parseResult, state, tokenizer.CloseSquareToken)
+
+	if close == nil || expect(parseResult, state, tokenizer.AssignToken) == nil {
+		return nil, false
+	}
+
+	value, ok := parseExpression(parseResult, state)
+
+	if !ok {
+		return nil, false
+	}
+
+	return &DestructuringDefinition{
+		keyword,
+		names,
+		close,
+		value,
+		keyword.Value == "var",
+		uniqueIds,
+		types,
+	}, true
+}
+
+func parseMatchStatement(parseResult *parseResult, state *parseState) (result *MatchStatement, okResult bool) {
+	matchKeyword := expectIdentifier(parseResult, state, "match")
+
+	if matchKeyword == nil {
+		return nil, false
+	}
+
+	subject, ok := parseExpression(parseResult, state)
+
+	if !ok || expect(parseResult, state, tokenizer.OpenCurlyToken) == nil {
+		return nil, false
+	}
+
+	var arms []*MatchArm = nil
+
+	for peek(state, 0).TokenType != tokenizer.CloseCurlyToken {
+		var variant = expect(parseResult, state, tokenizer.IDToken)
+
+		if variant == nil || expect(parseResult, state, tokenizer.FatArrowToken) == nil {
+			return nil, false
+		}
+
+		var body = parseBody(parseResult, state)
+
+		if body == nil {
+			return nil, false
+		}
+
+		arms = append(arms, &MatchArm{variant, body, nil, nil})
+	}
+
+	var close = expect(parseResult, state, tokenizer.CloseCurlyToken)
+
+	if close == nil {
+		return nil, false
+	}
+
+	return &MatchStatement{
+		matchKeyword,
+		subject,
+		arms,
+		close,
+	}, true
+}
+
+// Arms of a match expression are separated by commas
+func parseMatchExpression(parseResult *parseResult, state *parseState) (result *MatchExpression, okResult bool) {
+	matchKeyword := expectIdentifier(parseResult, state, "match")
+
+	if matchKeyword == nil {
+		return nil, false
+	}
+
+	subject, ok := parseExpression(parseResult, state)
+
+	if !ok || expect(parseResult, state, tokenizer.OpenCurlyToken) == nil {
+		return nil, false
+	}
+
+	var arms []*MatchArm = nil
+	var hasNext = peek(state, 0).TokenType != tokenizer.CloseCurlyToken
+
+	for hasNext {
+		var variant = expect(parseResult, state, tokenizer.IDToken)
+
+		if variant == nil || expect(parseResult, state, tokenizer.FatArrowToken) == nil {
+			return nil, false
+		}
+
+		value, ok := parseExpression(parseResult, state)
+
+		if !ok {
+			return nil, false
+		}
+
+		arms = append(arms, &MatchArm{variant, nil, value, nil})
+
+		hasNext = checkHasNext(state, tokenizer.CloseCurlyToken)
+	}
+
+	var close = expect(parseResult, state, tokenizer.CloseCurlyToken)
+
+	if close == nil {
+		return nil, false
+	}
+
+	return &MatchExpression{
+		matchKeyword,
+		subject,
+		arms,
+		close,
+		&UndefinedType{},
+	}, true
+}
+
+func parseStatement(parseResult *parseResult, state *parseState) (result Statement, okResult bool) {
+	var next = peek(state, 0)
+
+	if (next.Value == "let" || next.Value == "var") && peek(state, 1).TokenType == tokenizer.OpenSqaureToken {
+		return parseDestructuringDefinition(parseResult, state)
+	}
+
+	if next.Value == "let" || next.Value == "var" {
+		return parseVariableDefinition(parseResult, state)
+	}
+
+	if next.Value == "while" {
+		return parseWhileStatement(parseResult, state)
+	}
+
+	if next.Value == "foreach" {
+		return parseForeachStatement(parseResult, state)
+	}
+
+	if next.Value == "match" {
+		return parseMatchStatement(parseResult, state)
+	}
+
+	if next.Value == "assert" || next.Value == "assume" {
+		advance(state)
+
+		expression, ok := parseExpression(parseResult, state)
+
+		if !ok {
+			return nil, false
+		}
+
+		return &AssertStatement{
+			next,
+			expression,
+			next.Value == "assume",
+		}, true
+	}
+
+	if next.Value == "unreachable" {
+		advance(state)
+
+		return &UnreachableStatement{
+			next,
+		}, true
+	}
+
+	if next.Value == "return" {
+		advance(state)
+
+		var after = peek(state, 0)
+
+		if after.TokenType == tokenizer.CloseCurlyToken || after.TokenType == tokenizer.SemicolonToken {
+			return &ReturnStatement{
+				next,
+				nil,
+				nil,
+			}, true
+		}
+
+		var expressions []Expression = nil
+		var hasNext = true
+
+		for hasNext {
+			var returnValue, ok = parseExpression(parseResult, state)
+			expressions = append(expressions, returnValue)
+			hasNext = ok && optional(state, tokenizer.CommaToken) != nil
+		}
+
+		return &ReturnStatement{
+			next,
+			expressions,
+			nil,
+		}, true
+	}
+
+	expression, ok := parseExpression(parseResult, state)
+
+	if !ok {
+		return nil, false
+	}
+
+	var assign = optional(state, tokenizer.AssignToken)
+
+	if assign == nil {
+		return expression, true
+	}
+
+	value, ok := parseExpression(parseResult, state)
+
+	if !ok {
+		return nil, false
+	}
+
+	return &AssignmentStatement{
+		expression,
+		assign,
+		value,
+	}, true
+}
+
+func parseBody(parseResult *parseResult, state *parseState) (result *Body) {
+	openToken := expect(parseResult, state, tokenizer.OpenCurlyToken)
+	var statements []Statement = nil
+
+	if openToken == nil {
+		return nil
+	}
+
+	closeToken := optional(state, tokenizer.CloseCurlyToken)
+
+	for closeToken == nil && peek(state, 0).TokenType != tokenizer.EOFToken {
+		statement, ok := parseStatement(parseResult, state)
+		if !ok {
+			return nil
+		}
+		optional(state, tokenizer.SemicolonToken)
+		statements = append(statements, statement)
+		closeToken = optional(state, tokenizer.CloseCurlyToken)
+	}
+
+	return &Body{
+		openToken,
+		statements,
+		&UndefinedType{},
+		CreateScope(),
 		closeToken,
 	}
 }
@@ -606,7 +1238,13 @@ func parseFunctionDefinition(parseResult *parseResult, state *parseState) (resul
 	if name == nil {
 		return nil, false
 	}
-	var function, ok = parseFunction(parseResult, state)
+	typeParameters, ok := parseTypeParameters(parseResult, state)
+
+	if !ok {
+		return nil, false
+	}
+
+	function, ok := parseFunction(parseResult, state)
 
 	if !ok {
 		return nil, false
@@ -614,7 +1252,9 @@ func parseFunctionDefinition(parseResult *parseResult, state *parseState) (resul
 
 	return &FunctionDefinition{
 		name,
+		typeParameters,
 		function,
+		false,
 	}, true
 }
 
@@ -694,23 +1334,312 @@ func parseIfStatement(parseResult *parseResult, state *parseState) (result *IfSt
 	}, true
 }
 
+func parseWhileStatement(parseResult *parseResult, state *parseState) (result *WhileStatement, okResult bool) {
+	whileKeyword := expectIdentifier(parseResult, state, "while")
+
+	if whileKeyword == nil {
+		return nil, false
+	}
+
+	if expect(parseResult, state, tokenizer.OpenParenToken) == nil {
+		return nil, false
+	}
+
+	var condition, ok = parseExpression(parseResult, state)
+
+	if !ok {
+		return nil, false
+	}
+
+	if expect(parseResult, state, tokenizer.CloseParenToken) == nil {
+		return nil, false
+	}
+
+	var invariant Expression = nil
+
+	if optionalIdentifier(state, "invariant") != nil {
+		invariant, ok = parseExpression(parseResult, state)
+
+		if !ok {
+			return nil, false
+		}
+	}
+
+	var decreases Expression = nil
+
+	if optionalIdentifier(state, "decreases") != nil {
+		decreases, ok = parseExpression(parseResult, state)
+
+		if !ok {
+			return nil, false
+		}
+	}
+
+	var body = parseBody(parseResult, state)
+
+	if body == nil {
+		return nil, false
+	}
+
+	return &WhileStatement{
+		whileKeyword,
+		condition,
+		invariant,
+		decreases,
+		body,
+	}, true
+}
+
+func isAccumulatorStart(state *parseState) bool {
+	return peek(state, 0).TokenType == tokenizer.IDToken &&
+		getExpressionOperatorPrecedence(peek(state, 1)) != noExpressionPrecedence &&
+		peek(state, 2).TokenType == tokenizer.AssignToken
+}
+
+// The name given to the elements of an accumulator loop can't be written in
+// source so it never hides another variable
+const accumulatorElementName = "@element"
+
+// foreach total += in values is the same as
+// foreach element in values { total = total + element }
+func parseAccumulator(parseResult *parseResult, state *parseState, foreachKeyword *tokenizer.Token) (result *ForeachStatement, okResult bool) {
+	target, ok := parseIdentifier(parseResult, state)
+
+	if !ok {
+		return nil, false
+	}
+
+	var operator = peek(state, 0)
+	advance(state)
+	var assign = expect(parseResult, state, tokenizer.AssignToken)
+
+	if assign == nil || expectIdentifier(parseResult, state, "in") == nil {
+		return nil, false
+	}
+
+	collection, ok := parseExpression(parseResult, state)
+
+	if !ok {
+		return nil, false
+	}
+
+	var element = &tokenizer.Token{
+		TokenType: tokenizer.IDToken,
+		Value:     accumulatorElementName,
+		At:        collection.Begin(),
+	}
+	var last = &state.tokens.Tokens[state.location-1]
+
+	var assignment = &AssignmentStatement{
+		target,
+		assign,
+		&BinaryExpression{
+			&Identifier{target.Token, &UndefinedType{}, nil},
+			operator,
+			&Identifier{element, &UndefinedType{}, nil},
+			&UndefinedType{},
+		},
+	}
+
+	return &ForeachStatement{
+		foreachKeyword,
+		element,
+		nil,
+		collection,
+		&Body{
+			operator,
+			[]Statement{assignment},
+			&UndefinedType{},
+			CreateScope(),
+			last,
+		},
+		getNextTypeId(),
+		getNextTypeId(),
+	}, true
+}
+
+func parseForeachStatement(parseResult *parseResult, state *parseState) (result *ForeachStatement, okResult bool) {
+	foreachKeyword := expectIdentifier(parseResult, state, "foreach")
+
+	if foreachKeyword == nil {
+		return nil, false
+	}
+
+	if isAccumulatorStart(state) {
+		return parseAccumulator(parseResult, state, foreachKeyword)
+	}
+
+	var element = expect(parseResult, state, tokenizer.IDToken)
+
+	if element == nil {
+		return nil, false
+	}
+
+	var index *tokenizer.Token = nil
+
+	if optional(state, tokenizer.CommaToken) != nil {
+		index = expect(parseResult, state, tokenizer.IDToken)
+
+		if index == nil {
+			return nil, false
+		}
+	}
+
+	if expectIdentifier(parseResult, state, "in") == nil {
+		return nil, false
+	}
+
+	collection, ok := parseExpression(parseResult, state)
+
+	if !ok {
+		return nil, false
+	}
+
+	var body = parseBody(parseResult, state)
+
+	if body == nil {
+		return nil, false
+	}
+
+	return &ForeachStatement{
+		foreachKeyword,
+		element,
+		index,
+		collection,
+		body,
+		getNextTypeId(),
+		getNextTypeId(),
+	}, true
+}
+
+func parseModulePath(parseResult *parseResult, state *parseState, keyword string) (result *ModulePath) {
+	keywordToken := expectIdentifier(parseResult, state, keyword)
+
+	if keywordToken == nil {
+		return nil
+	}
+
+	var name = expect(parseResult, state, tokenizer.IDToken)
+
+	if name == nil {
+		return nil
+	}
+
+	var names = []*tokenizer.Token{name}
+
+	for optional(state, tokenizer.DotToken) != nil {
+		name = expect(parseResult, state, tokenizer.IDToken)
+
+		if name == nil {
+			return nil
+		}
+
+		names = append(names, name)
+	}
+
+	return &ModulePath{keywordToken, names}
+}
+
+func isPublicDefinition(state *parseState) bool {
+	return peek(state, 0).Value == "pub" &&
+		(peek(state, 1).Value == "func" || peek(state, 1).Value == "type" || peek(state, 1).Value == "const")
+}
+
+func parseConstantDefinition(parseResult *parseResult, state *parseState) (result *ConstantDefinition) {
+	constKeyword := expectIdentifier(parseResult, state, "const")
+
+	if constKeyword == nil {
+		return nil
+	}
+
+	var name = expect(parseResult, state, tokenizer.IDToken)
+
+	if name == nil {
+		return nil
+	}
+
+	var typeExp TypeExpression = nil
+
+	if optional(state, tokenizer.ColonToken) != nil {
+		var ok bool
+		typeExp, ok = parseType(parseResult, state)
+
+		if !ok {
+			return nil
+		}
+	}
+
+	if expect(parseResult, state, tokenizer.AssignToken) == nil {
+		return nil
+	}
+
+	value, ok := parseExpression(parseResult, state)
+
+	if !ok {
+		return nil
+	}
+
+	var whereExp Expression = nil
+
+	if peek(state, 0).TokenType == tokenizer.IDToken && peek(state, 0).Value == "where" {
+		advance(state)
+		whereExp, ok = parseExpression(parseResult, state)
+
+		if !ok {
+			return nil
+		}
+	}
+
+	return &ConstantDefinition{
+		constKeyword,
+		name,
+		typeExp,
+		value,
+		whereExp,
+		&UndefinedType{},
+		false,
+	}
+}
+
 func parseFileDefinition(parseResult *parseResult, state *parseState) (result *FileDefinition) {
 	fileStart := peek(state, 0).At
 
+	var module *ModulePath = nil
+	var imports []*ModulePath = nil
 	var definitions []Definition = nil
 
+	if peek(state, 0).Value == "module" {
+		module = parseModulePath(parseResult, state, "module")
+	}
+
 	var inError = false
 
 	for peek(state, 0).TokenType != tokenizer.EOFToken {
+		var isPublic = isPublicDefinition(state)
+
+		if isPublic {
+			advance(state)
+		}
+
 		var next = peek(state, 0)
 
-		if next.Value == "func" {
+		if next.Value == "import" && !isPublic {
+			var importPath = parseModulePath(parseResult, state, "import")
+
+			if importPath == nil {
+				inError = true
+			} else {
+				inError = false
+				imports = append(imports, importPath)
+			}
+		} else if next.Value == "func" {
 			var funcDef, ok = parseFunctionDefinition(parseResult, state)
 
 			if !ok {
 				inError = true
 			} else {
 				inError = false
+				funcDef.IsPublic = isPublic
 				definitions = append(definitions, funcDef)
 			}
 		} else if next.Value == "type" {
@@ -720,8 +1649,19 @@ func parseFileDefinition(parseResult *parseResult, state *parseState) (result *F
 				inError = true
 			} else {
 				inError = false
+				typeDef.IsPublic = isPublic
 				definitions = append(definitions, typeDef)
 			}
+		} else if next.Value == "const" {
+			var constDef = parseConstantDefinition(parseResult, state)
+
+			if constDef == nil {
+				inError = true
+			} else {
+				inError = false
+				constDef.IsPublic = isPublic
+				definitions = append(definitions, constDef)
+			}
 		} else {
 			if !inError {
 				parseResult.errors = append(parseResult.errors, CreateError(next.At, "Unexpected token '"+next.Value+"'"))
@@ -734,6 +1674,8 @@ func parseFileDefinition(parseResult *parseResult, state *parseState) (result *F
 
 	return &FileDefinition{
 		fileStart,
+		module,
+		imports,
 		definitions,
 		CreateScope(),
 		peek(state, 0).At,
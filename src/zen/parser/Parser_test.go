@@ -325,3 +325,661 @@ func TestOperators(t *testing.T) {
 	checkIdentifier(t, binaryExp.Right, "c")
 	checkToken(t, binaryExp.Operator, "*", tokenizer.MultiplyToken)
 }
+
+func TestNotOperator(t *testing.T) {
+	var tokens = tokenizer.Tokenize(source.SourceFromString("!a && b"))
+	var state = createState(&tokens)
+	var result = createParseResult()
+
+	expType, _ := parseExpression(&result, &state)
+	binaryExp, ok := expType.(*BinaryExpression)
+
+	if !ok {
+		t.Error("Expected binary expression")
+		return
+	}
+
+	checkIdentifier(t, binaryExp.Right, "b")
+	checkToken(t, binaryExp.Operator, "&&", tokenizer.BooleanAndToken)
+
+	unaryExp, ok := binaryExp.Left.(*UnaryExpression)
+
+	if !ok {
+		t.Error("Expected ! to bind tighter than &&")
+		return
+	}
+
+	checkIdentifier(t, unaryExp.Expr, "a")
+	checkToken(t, unaryExp.Operator, "!", tokenizer.NotToken)
+}
+
+func TestVariableDefinition(t *testing.T) {
+	var tokens = tokenizer.Tokenize(source.SourceFromString("{let a = 1; var b: i32 = a; var c: i32; b = c}"))
+	var state = createState(&tokens)
+	var result = createParseResult()
+
+	var body = parseBody(&result, &state)
+
+	if body == nil || len(result.errors) != 0 {
+		t.Fatal("Expected body to parse")
+	}
+
+	if len(body.Statements) != 4 {
+		t.Fatalf("Expected 4 statements got %d", len(body.Statements))
+	}
+
+	letDef, ok := body.Statements[0].(*VariableDefinition)
+
+	if !ok || letDef.IsMutable || letDef.TypeExp != nil || letDef.Value == nil {
+		t.Error("Expected immutable definition with a value")
+	}
+
+	varDef, ok := body.Statements[1].(*VariableDefinition)
+
+	if !ok || !varDef.IsMutable {
+		t.Error("Expected mutable definition")
+	} else {
+		checkTypeIdentifier(t, varDef.TypeExp, "i32")
+		checkIdentifier(t, varDef.Value, "a")
+	}
+
+	varDef, ok = body.Statements[2].(*VariableDefinition)
+
+	if !ok || varDef.Value != nil {
+		t.Error("Expected definition without a value")
+	}
+
+	assignment, ok := body.Statements[3].(*AssignmentStatement)
+
+	if !ok {
+		t.Error("Expected assignment")
+	} else {
+		checkIdentifier(t, assignment.Target, "b")
+		checkIdentifier(t, assignment.Value, "c")
+	}
+
+	tokens = tokenizer.Tokenize(source.SourceFromString("{let a}"))
+	state = createState(&tokens)
+	result = createParseResult()
+
+	parseBody(&result, &state)
+
+	if len(result.errors) == 0 {
+		t.Error("let without a value should not parse")
+	}
+}
+
+func TestAssertStatements(t *testing.T) {
+	var tokens = tokenizer.Tokenize(source.SourceFromString("{assert a; assume b; unreachable}"))
+	var state = createState(&tokens)
+	var result = createParseResult()
+
+	var body = parseBody(&result, &state)
+
+	if body == nil || len(result.errors) != 0 {
+		t.Fatal("Expected body to parse")
+	}
+
+	if len(body.Statements) != 3 {
+		t.Fatalf("Expected 3 statements got %d", len(body.Statements))
+	}
+
+	assert, ok := body.Statements[0].(*AssertStatement)
+
+	if !ok || assert.IsAssumption {
+		t.Error("Expected assert")
+	} else {
+		checkIdentifier(t, assert.Expression, "a")
+	}
+
+	assume, ok := body.Statements[1].(*AssertStatement)
+
+	if !ok || !assume.IsAssumption {
+		t.Error("Expected assume")
+	} else {
+		checkIdentifier(t, assume.Expression, "b")
+	}
+
+	if _, ok := body.Statements[2].(*UnreachableStatement); !ok {
+		t.Error("Expected unreachable")
+	}
+}
+
+func TestCallExpression(t *testing.T) {
+	var tokens = tokenizer.Tokenize(source.SourceFromString("Min(a, b + 1).result"))
+	var state = createState(&tokens)
+	var result = createParseResult()
+
+	expression, _ := parseExpression(&result, &state)
+
+	if len(result.errors) != 0 {
+		t.Fatal("Expected call to parse")
+	}
+
+	property, ok := expression.(*PropertyExpression)
+
+	if !ok {
+		t.Fatal("Expected property of call result")
+	}
+
+	call, ok := property.Left.(*CallExpression)
+
+	if !ok {
+		t.Fatal("Expected call expression")
+	}
+
+	checkIdentifier(t, call.Function, "Min")
+
+	if len(call.Arguments) != 2 {
+		t.Fatalf("Expected 2 arguments got %d", len(call.Arguments))
+	}
+
+	checkIdentifier(t, call.Arguments[0], "a")
+
+	_, ok = call.Arguments[1].(*BinaryExpression)
+
+	if !ok {
+		t.Error("Expected binary expression argument")
+	}
+
+	tokens = tokenizer.Tokenize(source.SourceFromString("Now()"))
+	state = createState(&tokens)
+	result = createParseResult()
+
+	expression, _ = parseExpression(&result, &state)
+	call, ok = expression.(*CallExpression)
+
+	if !ok || len(call.Arguments) != 0 || len(result.errors) != 0 {
+		t.Error("Expected call without arguments")
+	}
+}
+
+func TestArrayType(t *testing.T) {
+	var tokens = tokenizer.Tokenize(source.SourceFromString("[values: []i32, rest: ...u8] => []"))
+	var state = createState(&tokens)
+	var result = createParseResult()
+
+	typeExp, _ := parseType(&result, &state)
+
+	if len(result.errors) != 0 {
+		t.Fatal("Expected type to parse")
+	}
+
+	fnType, ok := typeExp.(*FunctionType)
+
+	if !ok {
+		t.Fatal("Expected function type")
+	}
+
+	input, _ := fnType.Input.(*StructureType)
+	values, ok := input.Entries[0].TypeExp.(*ArrayType)
+
+	if !ok || values.IsVariadic {
+		t.Error("Expected array type")
+	} else {
+		checkTypeIdentifier(t, values.ElementType, "i32")
+	}
+
+	rest, ok := input.Entries[1].TypeExp.(*ArrayType)
+
+	if !ok || !rest.IsVariadic {
+		t.Error("Expected variadic array type")
+	} else {
+		checkTypeIdentifier(t, rest.ElementType, "u8")
+	}
+
+	_, ok = fnType.Output.(*StructureType)
+
+	if !ok {
+		t.Error("Expected empty brackets to be an empty structure")
+	}
+}
+
+func TestIndexExpression(t *testing.T) {
+	var tokens = tokenizer.Tokenize(source.SourceFromString("array.data[array.length - 1]"))
+	var state = createState(&tokens)
+	var result = createParseResult()
+
+	expression, _ := parseExpression(&result, &state)
+	index, ok := expression.(*IndexExpression)
+
+	if len(result.errors) != 0 || !ok {
+		t.Fatal("Expected index expression")
+	}
+
+	_, ok = index.Left.(*PropertyExpression)
+
+	if !ok {
+		t.Error("Expected property to be indexed")
+	}
+
+	_, ok = index.Index.(*BinaryExpression)
+
+	if !ok {
+		t.Error("Expected binary expression index")
+	}
+}
+
+func TestTypeParameters(t *testing.T) {
+	var fileDef, errors = Parse(source.SourceFromString(`
+		type ArrayList(T) [data: []T, length: u32] where length <= Len(data)
+		func Len(T)[list: ArrayList(T)] => [r: u32] { return list.length }
+		type Pair [a: i32] => [b: i32]
+	`))
+
+	if len(errors) != 0 {
+		t.Fatal("Unexpected parse errors")
+	}
+
+	typeDef, ok := fileDef.Definitions[0].(*TypeDefinition)
+
+	if !ok || len(typeDef.TypeParameters) != 1 || typeDef.TypeParameters[0].Name.Value != "T" {
+		t.Error("Expected type definition with a type parameter")
+	}
+
+	fnDef, ok := fileDef.Definitions[1].(*FunctionDefinition)
+
+	if !ok || len(fnDef.TypeParameters) != 1 {
+		t.Fatal("Expected function definition with a type parameter")
+	}
+
+	fnType, _ := fnDef.Function.TypeExp.(*FunctionType)
+	input, _ := fnType.Input.(*StructureType)
+	list, ok := input.Entries[0].TypeExp.(*NamedType)
+
+	if !ok || len(list.TypeArguments) != 1 {
+		t.Error("Expected instantiated type")
+	} else {
+		checkTypeIdentifier(t, list.TypeArguments[0], "T")
+	}
+
+	pair, ok := fileDef.Definitions[2].(*TypeDefinition)
+
+	if !ok || len(pair.TypeParameters) != 0 {
+		t.Error("Function types should not be type parameters")
+	}
+}
+
+func TestMutableEntries(t *testing.T) {
+	var tokens = tokenizer.Tokenize(source.SourceFromString("[mut data: []i32, length: u32, mut: i32]"))
+	var state = createState(&tokens)
+	var result = createParseResult()
+
+	typeExp, _ := parseType(&result, &state)
+	structure, ok := typeExp.(*StructureType)
+
+	if len(result.errors) != 0 || !ok || len(structure.Entries) != 3 {
+		t.Fatal("Expected structure type")
+	}
+
+	if structure.Entries[0].Name.Value != "data" || !structure.Entries[0].IsMutable {
+		t.Error("Expected mutable entry")
+	}
+
+	if structure.Entries[1].IsMutable {
+		t.Error("Expected immutable entry")
+	}
+
+	if structure.Entries[2].Name.Value != "mut" || structure.Entries[2].IsMutable {
+		t.Error("Expected mut to be usable as a name")
+	}
+}
+
+func TestPostMarker(t *testing.T) {
+	var tokens = tokenizer.Tokenize(source.SourceFromString("n > 0 && post list.length == old(list.length) + 1"))
+	var state = createState(&tokens)
+	var result = createParseResult()
+	state.inWhere = true
+
+	expression, _ := parseExpression(&result, &state)
+	and, ok := expression.(*BinaryExpression)
+
+	if len(result.errors) != 0 || !ok {
+		t.Fatal("Expected binary expression")
+	}
+
+	post, ok := and.Right.(*UnaryExpression)
+
+	if !ok || post.Operator.Value != "post" {
+		t.Fatal("Expected post marker")
+	}
+
+	_, ok = post.Expr.(*BinaryExpression)
+
+	if !ok {
+		t.Error("Expected post marker to apply to the comparison")
+	}
+
+	tokens = tokenizer.Tokenize(source.SourceFromString("post + 1"))
+	state = createState(&tokens)
+	result = createParseResult()
+
+	expression, _ = parseExpression(&result, &state)
+	_, ok = expression.(*BinaryExpression)
+
+	if len(result.errors) != 0 || !ok {
+		t.Error("Expected post to be usable as a name")
+	}
+
+	tokens = tokenizer.Tokenize(source.SourceFromString("r > post - 1"))
+	state = createState(&tokens)
+	result = createParseResult()
+	state.inWhere = true
+
+	expression, _ = parseExpression(&result, &state)
+	comparison, ok := expression.(*BinaryExpression)
+
+	if len(result.errors) != 0 || !ok {
+		t.Fatal("Expected binary expression")
+	}
+
+	_, ok = comparison.Right.(*BinaryExpression)
+
+	if !ok {
+		t.Error("Expected post to be a name outside of clause position")
+	}
+
+	fileDef, errors := Parse(source.SourceFromString(`
+		func Previous[post: i32] => [r: i32] {
+			var r = post - 1
+			return r
+		}
+	`))
+
+	if len(errors) != 0 {
+		t.Fatal("Unexpected parse errors")
+	}
+
+	fnDef, _ := fileDef.Definitions[0].(*FunctionDefinition)
+	variable, ok := fnDef.Function.Body.Statements[0].(*VariableDefinition)
+
+	if !ok {
+		t.Fatal("Expected variable definition")
+	}
+
+	_, ok = variable.Value.(*BinaryExpression)
+
+	if !ok {
+		t.Error("Expected post to be usable as a name in a function body")
+	}
+}
+
+func TestWhileStatement(t *testing.T) {
+	var fileDef, errors = Parse(source.SourceFromString(`
+		func Count[n: u32] => [r: u32] {
+			var i: u32 = 0
+			while (i < n) invariant i <= n decreases n - i {
+				i = i + 1
+			}
+			while (i > 0) {
+				i = i - 1
+			}
+			return i
+		}
+	`))
+
+	if len(errors) != 0 {
+		t.Fatal("Unexpected parse errors")
+	}
+
+	fnDef, _ := fileDef.Definitions[0].(*FunctionDefinition)
+	loop, ok := fnDef.Function.Body.Statements[1].(*WhileStatement)
+
+	if !ok {
+		t.Fatal("Expected while statement")
+	}
+
+	if loop.Invariant == nil || loop.Decreases == nil || len(loop.Body.Statements) != 1 {
+		t.Error("Expected invariant, measure and body")
+	}
+
+	loop, ok = fnDef.Function.Body.Statements[2].(*WhileStatement)
+
+	if !ok || loop.Invariant != nil || loop.Decreases != nil {
+		t.Error("Expected while statement without invariant or measure")
+	}
+}
+
+func TestForeachStatement(t *testing.T) {
+	var fileDef, errors = Parse(source.SourceFromString(`
+		func Sum[values: []i32] => [r: i32] {
+			var total: i32 = 0
+			foreach value, index in values {
+				total = total + value
+			}
+			foreach value in values {
+			}
+			foreach total += in values
+			return total
+		}
+	`))
+
+	if len(errors) != 0 {
+		t.Fatal("Unexpected parse errors")
+	}
+
+	fnDef, _ := fileDef.Definitions[0].(*FunctionDefinition)
+	loop, ok := fnDef.Function.Body.Statements[1].(*ForeachStatement)
+
+	if !ok || loop.Element.Value != "value" || loop.Index == nil || loop.Index.Value != "index" {
+		t.Fatal("Expected foreach with element and index")
+	}
+
+	loop, ok = fnDef.Function.Body.Statements[2].(*ForeachStatement)
+
+	if !ok || loop.Index != nil {
+		t.Error("Expected foreach without an index")
+	}
+
+	loop, ok = fnDef.Function.Body.Statements[3].(*ForeachStatement)
+
+	if !ok || len(loop.Body.Statements) != 1 {
+		t.Fatal("Expected accumulator to be a foreach loop")
+	}
+
+	assignment, ok := loop.Body.Statements[0].(*AssignmentStatement)
+
+	if !ok {
+		t.Fatal("Expected accumulator to assign")
+	}
+
+	value, ok := assignment.Value.(*BinaryExpression)
+
+	if !ok || value.Operator.TokenType != tokenizer.AddToken {
+		t.Error("Expected accumulator to add each element")
+	} else {
+		element, _ := value.Right.(*Identifier)
+
+		if element == nil || element.Token != loop.Element {
+			t.Error("Expected accumulator to use the loop element")
+		}
+	}
+}
+
+func TestLambdaExpression(t *testing.T) {
+	var tokens = tokenizer.Tokenize(source.SourceFromString("Apply([a: u8] => [r: u8] where r > a { return a + 1 }, [a: 1])"))
+	var state = createState(&tokens)
+	var result = createParseResult()
+
+	expression, _ := parseExpression(&result, &state)
+	call, ok := expression.(*CallExpression)
+
+	if len(result.errors) != 0 || !ok || len(call.Arguments) != 2 {
+		t.Fatal("Expected call expression")
+	}
+
+	lambda, ok := call.Arguments[0].(*Function)
+
+	if !ok || len(lambda.Body.Statements) != 1 {
+		t.Error("Expected lambda")
+	}
+
+	_, ok = call.Arguments[1].(*StructureExpression)
+
+	if !ok {
+		t.Error("Expected structure expression")
+	}
+}
+
+func TestDestructuringDefinition(t *testing.T) {
+	var fileDef, errors = Parse(source.SourceFromString(`
+		func Split[range: Range, at: i32] => [r: i32] {
+			let [lo, hi] = Bisect(range, at)
+			var [only] = range
+			let value = Bisect(range, at).b
+			return lo.Max
+		}
+	`))
+
+	if len(errors) != 0 {
+		t.Fatal("Unexpected parse errors")
+	}
+
+	fnDef, _ := fileDef.Definitions[0].(*FunctionDefinition)
+	destructure, ok := fnDef.Function.Body.Statements[0].(*DestructuringDefinition)
+
+	if !ok || len(destructure.Names) != 2 || destructure.Names[1].Value != "hi" || destructure.IsMutable {
+		t.Fatal("Expected let to bind two names")
+	}
+
+	if _, ok := destructure.Value.(*CallExpression); !ok {
+		t.Error("Expected destructuring of a call")
+	}
+
+	destructure, ok = fnDef.Function.Body.Statements[1].(*DestructuringDefinition)
+
+	if !ok || len(destructure.Names) != 1 || !destructure.IsMutable {
+		t.Error("Expected var to bind one mutable name")
+	}
+
+	if _, ok := fnDef.Function.Body.Statements[2].(*VariableDefinition); !ok {
+		t.Error("Expected plain let to stay a variable definition")
+	}
+}
+
+func TestUnionAndMatch(t *testing.T) {
+	var fileDef, errors = Parse(source.SourceFromString(`
+		type Shape = Circle[r: i32] where r > 0 | Rect[w: i32, h: i32] | Empty
+
+		func Width[shape: Shape] => [r: i32] {
+			match shape {
+				Circle => { return shape.r }
+				Rect => { return shape.w }
+				Empty => { return 0 }
+			}
+		}
+
+		func Height[shape: Shape] => [r: i32] {
+			let height = match shape { Circle => shape.r, Rect => shape.h, Empty => 0, }
+			return height
+		}
+	`))
+
+	if len(errors) != 0 {
+		t.Fatal("Unexpected parse errors")
+	}
+
+	typeDef, _ := fileDef.Definitions[0].(*TypeDefinition)
+	union, ok := typeDef.TypeExp.(*UnionType)
+
+	if !ok || len(union.Variants) != 3 {
+		t.Fatal("Expected union with three variants")
+	}
+
+	if _, ok := union.Variants[0].TypeExp.(*WhereType); !ok || union.Variants[0].Name.Value != "Circle" {
+		t.Error("Expected variant with a where expression")
+	}
+
+	if union.Variants[2].TypeExp != nil {
+		t.Error("Expected variant without fields")
+	}
+
+	fnDef, _ := fileDef.Definitions[1].(*FunctionDefinition)
+	match, ok := fnDef.Function.Body.Statements[0].(*MatchStatement)
+
+	if !ok || len(match.Arms) != 3 || match.Arms[1].Variant.Value != "Rect" {
+		t.Fatal("Expected match with three arms")
+	}
+
+	fnDef, _ = fileDef.Definitions[2].(*FunctionDefinition)
+	variable, _ := fnDef.Function.Body.Statements[0].(*VariableDefinition)
+	matchExpression, ok := variable.Value.(*MatchExpression)
+
+	if !ok || len(matchExpression.Arms) != 3 || matchExpression.Arms[2].Value == nil {
+		t.Error("Expected match expression with three arms")
+	}
+}
+
+func TestModules(t *testing.T) {
+	var fileDef, errors = Parse(source.SourceFromString(`
+		module geometry.shapes
+		import geometry.points
+
+		pub type Square [pub mut size: i32, mut area: i32]
+
+		pub func Area[s: Square] => [r: i32] {
+			return s.area
+		}
+
+		func hidden[] => [r: i32] {
+			return 1
+		}
+	`))
+
+	if len(errors) != 0 {
+		t.Fatal("Unexpected parse errors")
+	}
+
+	if fileDef.Module == nil || fileDef.Module.Name() != "geometry.shapes" {
+		t.Fatal("Expected a module declaration")
+	}
+
+	if len(fileDef.Imports) != 1 || fileDef.Imports[0].Name() != "geometry.points" {
+		t.Fatal("Expected one import")
+	}
+
+	typeDef, _ := fileDef.Definitions[0].(*TypeDefinition)
+	structure, ok := typeDef.TypeExp.(*StructureType)
+
+	if !typeDef.IsPublic || !ok || len(structure.Entries) != 2 {
+		t.Fatal("Expected a public structure type")
+	}
+
+	if !structure.Entries[0].IsPublic || !structure.Entries[0].IsMutable || structure.Entries[1].IsPublic {
+		t.Error("Expected only the first field to be public")
+	}
+
+	fnDef, _ := fileDef.Definitions[1].(*FunctionDefinition)
+	hidden, _ := fileDef.Definitions[2].(*FunctionDefinition)
+
+	if !fnDef.IsPublic || hidden.IsPublic {
+		t.Error("Expected only the first function to be public")
+	}
+}
+
+func TestConstantDefinition(t *testing.T) {
+	var fileDef, errors = Parse(source.SourceFromString(`
+		const MaxPlayers = 16
+		pub const MaxTeams: u8 = MaxPlayers / 2 where MaxTeams > 1
+	`))
+
+	if len(errors) != 0 {
+		t.Fatal("Unexpected parse errors")
+	}
+
+	constDef, ok := fileDef.Definitions[0].(*ConstantDefinition)
+
+	if !ok || constDef.Name.Value != "MaxPlayers" || constDef.TypeExp != nil || constDef.WhereExp != nil || constDef.IsPublic {
+		t.Fatal("Expected a constant without type or where expression")
+	}
+
+	constDef, ok = fileDef.Definitions[1].(*ConstantDefinition)
+
+	if !ok || constDef.TypeExp == nil || constDef.WhereExp == nil || !constDef.IsPublic {
+		t.Fatal("Expected a public constant with type and where expression")
+	}
+
+	if _, ok := constDef.Value.(*BinaryExpression); !ok {
+		t.Error("Expected the value to stop before where")
+	}
+}
@@ -1,5 +1,7 @@
 package parser
 
+import "strconv"
+
 type TypeNodeType int
 
 const (
@@ -9,6 +11,11 @@ const (
 	BooleanNodeType
 	StructureNodeType
 	FunctionNodeType
+	ArrayNodeType
+	BuiltinFunctionNodeType
+	TypeParameterNodeType
+	GenericFunctionNodeType
+	UnionNodeType
 )
 
 type TypeNode interface {
@@ -32,6 +39,10 @@ func getNextTypeId() int {
 	return nextTypeId
 }
 
+func NextUniqueId() int {
+	return getNextTypeId()
+}
+
 type UndefinedType struct {
 }
 
@@ -106,6 +117,43 @@ func NewIntegerType(bitCount int, isSigned bool) *IntegerType {
 	}
 }
 
+func (integerType *IntegerType) Name() string {
+	if integerType.IsSigned {
+		return "i" + strconv.Itoa(integerType.BitCount)
+	} else {
+		return "u" + strconv.Itoa(integerType.BitCount)
+	}
+}
+
+func (integerType *IntegerType) MinValue() int64 {
+	if integerType.IsSigned {
+		return -(int64(1) << uint(integerType.BitCount-1))
+	} else {
+		return 0
+	}
+}
+
+// Constraints are solved with 64 bit signed values so u64 values above the
+// maximum i64 value are never assumed to exist
+func (integerType *IntegerType) MaxValue() int64 {
+	if integerType.IsSigned || integerType.BitCount >= 64 {
+		return int64(^uint64(0) >> uint(65-integerType.BitCount))
+	} else {
+		return int64(^uint64(0) >> uint(64-integerType.BitCount))
+	}
+}
+
+// Checks if every value of other can also be stored in this type
+func (integerType *IntegerType) ContainsRange(other *IntegerType) bool {
+	if integerType.IsSigned == other.IsSigned {
+		return integerType.BitCount >= other.BitCount
+	} else if integerType.IsSigned {
+		return integerType.BitCount > other.BitCount
+	} else {
+		return false
+	}
+}
+
 func (integerType *IntegerType) GetSubType(name string) TypeNode {
 	return &UndefinedType{}
 }
@@ -168,17 +216,24 @@ func (booleanType *BooleanType) UniqueId() int {
 	return booleanType.uniqueID
 }
 
+// Fields that aren't public can only be modified inside of Module
 type StructureNamedEntryType struct {
-	Name     string
-	UniqueId int
-	Type     TypeNode
+	Name      string
+	UniqueId  int
+	Type      TypeNode
+	IsMutable bool
+	IsPublic  bool
+	Module    string
 }
 
-func NewStructureNamedEntryType(name string, typeNode TypeNode) *StructureNamedEntryType {
+func NewStructureNamedEntryType(name string, typeNode TypeNode, isMutable bool) *StructureNamedEntryType {
 	return &StructureNamedEntryType{
 		name,
 		getNextTypeId(),
 		typeNode,
+		isMutable,
+		true,
+		"",
 	}
 }
 
@@ -294,3 +349,222 @@ func (functionType *FunctionTypeType) SetWhereExpression(expression Expression)
 func (functionType *FunctionTypeType) UniqueId() int {
 	return functionType.uniqueId
 }
+
+type ArrayTypeType struct {
+	ElementType     TypeNode
+	uniqueId        int
+	whereExpression Expression
+}
+
+func NewArrayTypeType(elementType TypeNode) *ArrayTypeType {
+	return &ArrayTypeType{
+		elementType,
+		getNextTypeId(),
+		&VoidExpression{},
+	}
+}
+
+func (arrayType *ArrayTypeType) GetSubType(name string) TypeNode {
+	if name == "length" {
+		return NewIntegerType(32, false)
+	}
+
+	return &UndefinedType{}
+}
+
+func (arrayType *ArrayTypeType) CanAssignFrom(other TypeNode) bool {
+	asArray, ok := other.(*ArrayTypeType)
+
+	return ok &&
+		arrayType.ElementType.CanAssignFrom(asArray.ElementType) &&
+		asArray.ElementType.CanAssignFrom(arrayType.ElementType)
+}
+
+func (arrayType *ArrayTypeType) GetNodeType() TypeNodeType {
+	return ArrayNodeType
+}
+
+func (arrayType *ArrayTypeType) GetWhereExpression() Expression {
+	return arrayType.whereExpression
+}
+
+func (arrayType *ArrayTypeType) SetWhereExpression(expression Expression) {
+	arrayType.whereExpression = expression
+}
+
+func (arrayType *ArrayTypeType) UniqueId() int {
+	return arrayType.uniqueId
+}
+
+// Functions provided by the compiler that cannot be written as a function
+// type, such as Len which accepts an array of any element type
+type BuiltinFunctionType struct {
+	Name     string
+	uniqueId int
+}
+
+func NewBuiltinFunctionType(name string) *BuiltinFunctionType {
+	return &BuiltinFunctionType{
+		name,
+		getNextTypeId(),
+	}
+}
+
+func (builtinType *BuiltinFunctionType) GetSubType(name string) TypeNode {
+	return &UndefinedType{}
+}
+
+func (builtinType *BuiltinFunctionType) CanAssignFrom(other TypeNode) bool {
+	return builtinType == other
+}
+
+func (builtinType *BuiltinFunctionType) GetNodeType() TypeNodeType {
+	return BuiltinFunctionNodeType
+}
+
+func (builtinType *BuiltinFunctionType) GetWhereExpression() Expression {
+	return &VoidExpression{}
+}
+
+func (builtinType *BuiltinFunctionType) SetWhereExpression(expression Expression) {
+	// noop
+}
+
+func (builtinType *BuiltinFunctionType) UniqueId() int {
+	return builtinType.uniqueId
+}
+
+// A type parameter of a generic type or function. It only accepts values of
+// the same type parameter since it could be replaced by any type
+type TypeParameterType struct {
+	Name            string
+	uniqueId        int
+	whereExpression Expression
+}
+
+func NewTypeParameterType(name string) *TypeParameterType {
+	return &TypeParameterType{
+		name,
+		getNextTypeId(),
+		&VoidExpression{},
+	}
+}
+
+func (parameterType *TypeParameterType) GetSubType(name string) TypeNode {
+	return &UndefinedType{}
+}
+
+func (parameterType *TypeParameterType) CanAssignFrom(other TypeNode) bool {
+	return parameterType == other
+}
+
+func (parameterType *TypeParameterType) GetNodeType() TypeNodeType {
+	return TypeParameterNodeType
+}
+
+func (parameterType *TypeParameterType) GetWhereExpression() Expression {
+	return parameterType.whereExpression
+}
+
+func (parameterType *TypeParameterType) SetWhereExpression(expression Expression) {
+	parameterType.whereExpression = expression
+}
+
+func (parameterType *TypeParameterType) UniqueId() int {
+	return parameterType.uniqueId
+}
+
+// A function with type parameters. Each call replaces the parameters with the
+// types inferred from its arguments
+type GenericFunctionType struct {
+	Function   *FunctionTypeType
+	Parameters []*TypeParameterType
+	uniqueId   int
+}
+
+func NewGenericFunctionType(function *FunctionTypeType, parameters []*TypeParameterType) *GenericFunctionType {
+	return &GenericFunctionType{
+		function,
+		parameters,
+		getNextTypeId(),
+	}
+}
+
+func (genericType *GenericFunctionType) GetSubType(name string) TypeNode {
+	return &UndefinedType{}
+}
+
+func (genericType *GenericFunctionType) CanAssignFrom(other TypeNode) bool {
+	return genericType == other
+}
+
+func (genericType *GenericFunctionType) GetNodeType() TypeNodeType {
+	return GenericFunctionNodeType
+}
+
+func (genericType *GenericFunctionType) GetWhereExpression() Expression {
+	return genericType.Function.GetWhereExpression()
+}
+
+func (genericType *GenericFunctionType) SetWhereExpression(expression Expression) {
+	genericType.Function.SetWhereExpression(expression)
+}
+
+func (genericType *GenericFunctionType) UniqueId() int {
+	return genericType.uniqueId
+}
+
+type UnionVariantType struct {
+	Name string
+	Type *StructureTypeType
+}
+
+// A value that is one of several named structures. The fields of a variant
+// can only be used once a match has found which variant it is
+type UnionTypeType struct {
+	Variants        []*UnionVariantType
+	uniqueId        int
+	whereExpression Expression
+}
+
+func NewUnionTypeType(variants []*UnionVariantType) *UnionTypeType {
+	return &UnionTypeType{
+		variants,
+		getNextTypeId(),
+		&VoidExpression{},
+	}
+}
+
+func (unionType *UnionTypeType) GetVariant(name string) *UnionVariantType {
+	for _, variant := range unionType.Variants {
+		if variant.Name == name {
+			return variant
+		}
+	}
+
+	return nil
+}
+
+func (unionType *UnionTypeType) GetSubType(name string) TypeNode {
+	return &UndefinedType{}
+}
+
+func (unionType *UnionTypeType) CanAssignFrom(other TypeNode) bool {
+	return unionType == other
+}
+
+func (unionType *UnionTypeType) GetNodeType() TypeNodeType {
+	return UnionNodeType
+}
+
+func (unionType *UnionTypeType) GetWhereExpression() Expression {
+	return unionType.whereExpression
+}
+
+func (unionType *UnionTypeType) SetWhereExpression(expression Expression) {
+	unionType.whereExpression = expression
+}
+
+func (unionType *UnionTypeType) UniqueId() int {
+	return unionType.uniqueId
+}
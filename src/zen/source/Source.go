@@ -46,6 +46,12 @@ func (source *Source) Length() int {
 }
 
 func FormatLine(source *Source, at int) (message string) {
+	return FormatSpan(source, at, at+1)
+}
+
+// Marks the source from at up to end, or up to the end of the line when the
+// span covers multiple lines
+func FormatSpan(source *Source, at int, end int) (message string) {
 	var lineNumber = 0
 	var colNumber = 0
 	var lineStart = 0
@@ -62,5 +68,16 @@ func FormatLine(source *Source, at int) (message string) {
 		lineStart = nextLineStart
 	}
 
-	return fmt.Sprintf("%s: (%d, %d)\n%s\n%s", source.name, lineNumber+1, colNumber+1, source.lines[lineNumber], strings.Repeat(" ", colNumber)+"^")
+	var line = source.lines[lineNumber]
+	var spanLength = end - at
+
+	if spanLength > len(line)-colNumber {
+		spanLength = len(line) - colNumber
+	}
+
+	if spanLength < 1 {
+		spanLength = 1
+	}
+
+	return fmt.Sprintf("%s: (%d, %d)\n%s\n%s", source.name, lineNumber+1, colNumber+1, line, strings.Repeat(" ", colNumber)+strings.Repeat("^", spanLength))
 }
@@ -10,45 +10,104 @@ type TokenType uint
 type tokenizerState func(next rune) (nextState tokenizerState, token TokenType)
 
 const (
-	NoToken          TokenType = 0
-	IDToken          TokenType = 1
-	ErrorToken       TokenType = 2
-	WhitespaceToken  TokenType = 3
-	EOFToken         TokenType = 4
-	NumberToken      TokenType = 5
-	OpenSqaureToken  TokenType = 6
-	CloseSquareToken TokenType = 7
-	OpenCurlyToken   TokenType = 8
-	CloseCurlyToken  TokenType = 9
-	OpenParenToken   TokenType = 10
-	CloseParenToken  TokenType = 11
-	ColonToken       TokenType = 12
-	FatArrowToken    TokenType = 13
-	AssignToken      TokenType = 14
-	EqualToken       TokenType = 15
-	AddToken         TokenType = 16
-	MinusToken       TokenType = 17
-	MultiplyToken    TokenType = 18
-	DivideToken      TokenType = 19
-	DotToken         TokenType = 20
-	CommaToken       TokenType = 21
-	SemicolonToken   TokenType = 22
-	BitwiseOrToken   TokenType = 23
-	BitwiseAndToken  TokenType = 24
-	BooleanOrToken   TokenType = 25
-	BooleanAndToken  TokenType = 26
-	LTToken          TokenType = 27
-	LTEqToken        TokenType = 28
-	GTToken          TokenType = 29
-	GTEqToken        TokenType = 30
-	NotToken         TokenType = 31
-	NotEqualToken    TokenType = 32
+	NoToken           TokenType = 0
+	IDToken           TokenType = 1
+	ErrorToken        TokenType = 2
+	WhitespaceToken   TokenType = 3
+	EOFToken          TokenType = 4
+	NumberToken       TokenType = 5
+	OpenSqaureToken   TokenType = 6
+	CloseSquareToken  TokenType = 7
+	OpenCurlyToken    TokenType = 8
+	CloseCurlyToken   TokenType = 9
+	OpenParenToken    TokenType = 10
+	CloseParenToken   TokenType = 11
+	ColonToken        TokenType = 12
+	FatArrowToken     TokenType = 13
+	AssignToken       TokenType = 14
+	EqualToken        TokenType = 15
+	AddToken          TokenType = 16
+	MinusToken        TokenType = 17
+	MultiplyToken     TokenType = 18
+	DivideToken       TokenType = 19
+	DotToken          TokenType = 20
+	CommaToken        TokenType = 21
+	SemicolonToken    TokenType = 22
+	BitwiseOrToken    TokenType = 23
+	BitwiseAndToken   TokenType = 24
+	BooleanOrToken    TokenType = 25
+	BooleanAndToken   TokenType = 26
+	LTToken           TokenType = 27
+	LTEqToken         TokenType = 28
+	GTToken           TokenType = 29
+	GTEqToken         TokenType = 30
+	NotToken          TokenType = 31
+	NotEqualToken     TokenType = 32
+	CommentToken      TokenType = 33
+	EllipsisToken     TokenType = 34
+	WrapAddToken      TokenType = 35
+	WrapMinusToken    TokenType = 36
+	WrapMultiplyToken TokenType = 37
+	ModToken          TokenType = 38
 )
 
+var tokenTypeNames = map[TokenType]string{
+	NoToken:           "None",
+	IDToken:           "Identifier",
+	ErrorToken:        "Error",
+	WhitespaceToken:   "Whitespace",
+	EOFToken:          "EOF",
+	NumberToken:       "Number",
+	OpenSqaureToken:   "OpenSquare",
+	CloseSquareToken:  "CloseSquare",
+	OpenCurlyToken:    "OpenCurly",
+	CloseCurlyToken:   "CloseCurly",
+	OpenParenToken:    "OpenParen",
+	CloseParenToken:   "CloseParen",
+	ColonToken:        "Colon",
+	FatArrowToken:     "FatArrow",
+	AssignToken:       "Assign",
+	EqualToken:        "Equal",
+	AddToken:          "Add",
+	MinusToken:        "Minus",
+	MultiplyToken:     "Multiply",
+	DivideToken:       "Divide",
+	DotToken:          "Dot",
+	CommaToken:        "Comma",
+	SemicolonToken:    "Semicolon",
+	BitwiseOrToken:    "BitwiseOr",
+	BitwiseAndToken:   "BitwiseAnd",
+	BooleanOrToken:    "BooleanOr",
+	BooleanAndToken:   "BooleanAnd",
+	LTToken:           "LessThan",
+	LTEqToken:         "LessThanEqual",
+	GTToken:           "GreaterThan",
+	GTEqToken:         "GreaterThanEqual",
+	NotToken:          "Not",
+	NotEqualToken:     "NotEqual",
+	CommentToken:      "Comment",
+	EllipsisToken:     "Ellipsis",
+	WrapAddToken:      "WrapAdd",
+	WrapMinusToken:    "WrapMinus",
+	WrapMultiplyToken: "WrapMultiply",
+	ModToken:          "Mod",
+}
+
+func (tokenType TokenType) String() string {
+	name, ok := tokenTypeNames[tokenType]
+
+	if ok {
+		return name
+	} else {
+		return "Unknown"
+	}
+}
+
 type Token struct {
-	TokenType TokenType
-	Value     string
-	At        SourceLocation
+	TokenType     TokenType
+	Value         string
+	At            SourceLocation
+	LeadingTrivia []Token
 }
 
 func (token *Token) End() SourceLocation {
@@ -87,15 +146,17 @@ func startState(next rune) (nextState tokenizerState) {
 	} else if next == ';' {
 		return outputTokenState(SemicolonToken)
 	} else if next == '+' {
-		return outputTokenState(AddToken)
+		return wrapState(AddToken, WrapAddToken)
 	} else if next == '-' {
-		return outputTokenState(MinusToken)
+		return wrapState(MinusToken, WrapMinusToken)
 	} else if next == '*' {
-		return outputTokenState(MultiplyToken)
+		return wrapState(MultiplyToken, WrapMultiplyToken)
 	} else if next == '/' {
-		return outputTokenState(DivideToken)
+		return slashState
+	} else if next == '%' {
+		return outputTokenState(ModToken)
 	} else if next == '.' {
-		return outputTokenState(DotToken)
+		return dotState
 	} else if next == ',' {
 		return outputTokenState(CommaToken)
 	} else if next == '=' {
@@ -154,6 +215,69 @@ func whitespaceState(next rune) (nextState tokenizerState, token TokenType) {
 	}
 }
 
+func slashState(next rune) (nextState tokenizerState, token TokenType) {
+	if next == '/' {
+		return lineCommentState, NoToken
+	} else if next == '*' {
+		return blockCommentState(1, 0), NoToken
+	} else {
+		return startState(next), DivideToken
+	}
+}
+
+func lineCommentState(next rune) (nextState tokenizerState, token TokenType) {
+	if next == '\n' || next == -1 {
+		return startState(next), CommentToken
+	} else {
+		return lineCommentState, NoToken
+	}
+}
+
+func blockCommentState(depth int, previous rune) (result tokenizerState) {
+	return func(next rune) (nextState tokenizerState, token TokenType) {
+		if next == -1 {
+			return errorState, ErrorToken
+		} else if previous == '*' && next == '/' {
+			if depth == 1 {
+				return outputTokenState(CommentToken), NoToken
+			} else {
+				return blockCommentState(depth-1, 0), NoToken
+			}
+		} else if previous == '/' && next == '*' {
+			return blockCommentState(depth+1, 0), NoToken
+		} else {
+			return blockCommentState(depth, next), NoToken
+		}
+	}
+}
+
+func dotState(next rune) (nextState tokenizerState, token TokenType) {
+	if next == '.' {
+		return dotDotState, NoToken
+	} else {
+		return startState(next), DotToken
+	}
+}
+
+func dotDotState(next rune) (nextState tokenizerState, token TokenType) {
+	if next == '.' {
+		return outputTokenState(EllipsisToken), NoToken
+	} else {
+		return errorState(next)
+	}
+}
+
+// Arithmetic operators followed by % wrap around instead of overflowing
+func wrapState(tokenType TokenType, wrapTokenType TokenType) (result tokenizerState) {
+	return func(next rune) (nextState tokenizerState, token TokenType) {
+		if next == '%' {
+			return outputTokenState(wrapTokenType), NoToken
+		} else {
+			return startState(next), tokenType
+		}
+	}
+}
+
 func equalState(next rune) (nextState tokenizerState, token TokenType) {
 	if next == '>' {
 		return outputTokenState(FatArrowToken), NoToken
@@ -216,27 +340,38 @@ func errorState(next rune) (nextState tokenizerState, token TokenType) {
 
 func Tokenize(src *source.Source) (result TokenizeResult) {
 	var tokens []Token
+	var trivia []Token
 	var currentState tokenizerState = defaultState
 
 	var textSource = source.GetSourceContent(src)
 
 	var currentTokenStart int = 0
 
+	var appendToken = func(tokenType TokenType, end int) {
+		var token = Token{
+			tokenType,
+			textSource[currentTokenStart:end],
+			SourceLocation{
+				src,
+				currentTokenStart,
+			},
+			nil,
+		}
+
+		if tokenType == CommentToken {
+			trivia = append(trivia, token)
+		} else if tokenType != WhitespaceToken {
+			token.LeadingTrivia = trivia
+			trivia = nil
+			tokens = append(tokens, token)
+		}
+	}
+
 	for index, character := range textSource {
 		var nextState, token = currentState(character)
 
 		if token != NoToken {
-			if token != WhitespaceToken {
-				tokens = append(tokens, Token{
-					token,
-					textSource[currentTokenStart:index],
-					SourceLocation{
-						src,
-						currentTokenStart,
-					},
-				})
-			}
-
+			appendToken(token, index)
 			currentTokenStart = index
 		}
 
@@ -245,25 +380,10 @@ func Tokenize(src *source.Source) (result TokenizeResult) {
 
 	var _, lastToken = currentState(-1)
 
-	if lastToken != WhitespaceToken {
-		tokens = append(tokens, Token{
-			lastToken,
-			textSource[currentTokenStart:len(textSource)],
-			SourceLocation{
-				src,
-				currentTokenStart,
-			},
-		})
-	}
+	appendToken(lastToken, len(textSource))
 
-	tokens = append(tokens, Token{
-		EOFToken,
-		"",
-		SourceLocation{
-			src,
-			len(textSource),
-		},
-	})
+	currentTokenStart = len(textSource)
+	appendToken(EOFToken, len(textSource))
 
 	return TokenizeResult{
 		tokens,
@@ -49,3 +49,59 @@ func TestOperators(t *testing.T) {
 	}
 	checkToken(t, tokenizeResult.Tokens[0], "=>", FatArrowToken)
 }
+
+func TestComments(t *testing.T) {
+	var source = source.SourceFromString("a // line comment\nb /* block /* nested */ comment */ c / d")
+	tokenizeResult := Tokenize(source)
+	if len(tokenizeResult.Tokens) != 6 {
+		t.Fatalf("Expected token length to be 6 but was %d", len(tokenizeResult.Tokens))
+	}
+	checkToken(t, tokenizeResult.Tokens[0], "a", IDToken)
+	checkToken(t, tokenizeResult.Tokens[1], "b", IDToken)
+	checkToken(t, tokenizeResult.Tokens[2], "c", IDToken)
+	checkToken(t, tokenizeResult.Tokens[3], "/", DivideToken)
+	checkToken(t, tokenizeResult.Tokens[4], "d", IDToken)
+
+	if len(tokenizeResult.Tokens[1].LeadingTrivia) != 1 {
+		t.Fatalf("Expected line comment to be attached to 'b'")
+	}
+	checkToken(t, tokenizeResult.Tokens[1].LeadingTrivia[0], "// line comment", CommentToken)
+
+	if len(tokenizeResult.Tokens[2].LeadingTrivia) != 1 {
+		t.Fatalf("Expected block comment to be attached to 'c'")
+	}
+	checkToken(t, tokenizeResult.Tokens[2].LeadingTrivia[0], "/* block /* nested */ comment */", CommentToken)
+}
+
+func TestUnterminatedComment(t *testing.T) {
+	var source = source.SourceFromString("a /* never closed")
+	tokenizeResult := Tokenize(source)
+	if len(tokenizeResult.Tokens) != 3 {
+		t.Fatalf("Expected token length to be 3 but was %d", len(tokenizeResult.Tokens))
+	}
+	checkToken(t, tokenizeResult.Tokens[1], "/* never closed", ErrorToken)
+}
+
+func TestEllipsis(t *testing.T) {
+	var source = source.SourceFromString("...i32 a.b")
+	tokenizeResult := Tokenize(source)
+	if len(tokenizeResult.Tokens) != 6 {
+		t.Errorf("Expected token length to be 6 but was %d", len(tokenizeResult.Tokens))
+	}
+	checkToken(t, tokenizeResult.Tokens[0], "...", EllipsisToken)
+	checkToken(t, tokenizeResult.Tokens[1], "i32", IDToken)
+	checkToken(t, tokenizeResult.Tokens[3], ".", DotToken)
+}
+
+func TestWrappingOperators(t *testing.T) {
+	var source = source.SourceFromString("a +% b -%c*%d + e % f")
+	tokenizeResult := Tokenize(source)
+	if len(tokenizeResult.Tokens) != 12 {
+		t.Errorf("Expected token length to be 12 but was %d", len(tokenizeResult.Tokens))
+	}
+	checkToken(t, tokenizeResult.Tokens[1], "+%", WrapAddToken)
+	checkToken(t, tokenizeResult.Tokens[3], "-%", WrapMinusToken)
+	checkToken(t, tokenizeResult.Tokens[5], "*%", WrapMultiplyToken)
+	checkToken(t, tokenizeResult.Tokens[7], "+", AddToken)
+	checkToken(t, tokenizeResult.Tokens[9], "%", ModToken)
+}
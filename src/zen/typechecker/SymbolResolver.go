@@ -53,12 +53,60 @@ func (symbolResolver *SymbolResolver) VisitStructureExpression(exp *parser.Struc
 	}
 }
 
+func (symbolResolver *SymbolResolver) VisitCallExpression(exp *parser.CallExpression) {
+	exp.Function.Accept(symbolResolver)
+
+	for _, argument := range exp.Arguments {
+		argument.Accept(symbolResolver)
+	}
+}
+
+func (symbolResolver *SymbolResolver) VisitIndexExpression(exp *parser.IndexExpression) {
+	exp.Left.Accept(symbolResolver)
+	exp.Index.Accept(symbolResolver)
+}
+
+func (symbolResolver *SymbolResolver) VisitMatchExpression(match *parser.MatchExpression) {
+	match.Subject.Accept(symbolResolver)
+
+	for _, arm := range match.Arms {
+		arm.Value.Accept(symbolResolver)
+	}
+}
+
 func (symbolResolver *SymbolResolver) VisitIf(ifStatement *parser.IfStatement) {
 	ifStatement.Expresssion.Accept(symbolResolver)
 	ifStatement.Body.Accept(symbolResolver)
 	ifStatement.ElseBody.Accept(symbolResolver)
 }
 
+func (symbolResolver *SymbolResolver) VisitWhile(whileStatement *parser.WhileStatement) {
+	whileStatement.Condition.Accept(symbolResolver)
+
+	if whileStatement.Invariant != nil {
+		whileStatement.Invariant.Accept(symbolResolver)
+	}
+
+	if whileStatement.Decreases != nil {
+		whileStatement.Decreases.Accept(symbolResolver)
+	}
+
+	whileStatement.Body.Accept(symbolResolver)
+}
+
+func (symbolResolver *SymbolResolver) VisitForeach(foreach *parser.ForeachStatement) {
+	foreach.Collection.Accept(symbolResolver)
+	foreach.Body.Accept(symbolResolver)
+}
+
+func (symbolResolver *SymbolResolver) VisitMatch(match *parser.MatchStatement) {
+	match.Subject.Accept(symbolResolver)
+
+	for _, arm := range match.Arms {
+		arm.Body.Accept(symbolResolver)
+	}
+}
+
 func (symbolResolver *SymbolResolver) VisitBody(body *parser.Body) {
 	for _, entry := range body.Statements {
 		entry.Accept(symbolResolver)
@@ -71,6 +119,33 @@ func (symbolResolver *SymbolResolver) VisitReturn(ret *parser.ReturnStatement) {
 	}
 }
 
+func (symbolResolver *SymbolResolver) VisitVarDef(varDef *parser.VariableDefinition) {
+	if varDef.TypeExp != nil {
+		varDef.TypeExp.Accept(symbolResolver)
+	}
+
+	if varDef.Value != nil {
+		varDef.Value.Accept(symbolResolver)
+	}
+}
+
+func (symbolResolver *SymbolResolver) VisitDestructure(destructure *parser.DestructuringDefinition) {
+	destructure.Value.Accept(symbolResolver)
+}
+
+func (symbolResolver *SymbolResolver) VisitAssignment(assignment *parser.AssignmentStatement) {
+	assignment.Target.Accept(symbolResolver)
+	assignment.Value.Accept(symbolResolver)
+}
+
+func (symbolResolver *SymbolResolver) VisitAssert(assert *parser.AssertStatement) {
+	assert.Expression.Accept(symbolResolver)
+}
+
+func (symbolResolver *SymbolResolver) VisitUnreachable(unreachable *parser.UnreachableStatement) {
+
+}
+
 func (symbolResolver *SymbolResolver) VisitNamedType(namedType *parser.NamedType) {
 
 }
@@ -86,11 +161,23 @@ func (symbolResolver *SymbolResolver) VisitFunctionType(fn *parser.FunctionType)
 	fn.Output.Accept(symbolResolver)
 }
 
+func (symbolResolver *SymbolResolver) VisitArrayType(array *parser.ArrayType) {
+	array.ElementType.Accept(symbolResolver)
+}
+
 func (symbolResolver *SymbolResolver) VisitWhereType(where *parser.WhereType) {
 	where.TypeExp.Accept(symbolResolver)
 	where.WhereExp.Accept(symbolResolver)
 }
 
+func (symbolResolver *SymbolResolver) VisitUnionType(union *parser.UnionType) {
+	for _, variant := range union.Variants {
+		if variant.TypeExp != nil {
+			variant.TypeExp.Accept(symbolResolver)
+		}
+	}
+}
+
 func (symbolResolver *SymbolResolver) VisitTypeDef(typeDef *parser.TypeDefinition) {
 	typeDef.TypeExp.Accept(symbolResolver)
 }
@@ -104,6 +191,14 @@ func (symbolResolver *SymbolResolver) VisitFnDef(fnDef *parser.FunctionDefinitio
 	fnDef.Function.Accept(symbolResolver)
 }
 
+func (symbolResolver *SymbolResolver) VisitConst(constDef *parser.ConstantDefinition) {
+	constDef.Value.Accept(symbolResolver)
+
+	if constDef.WhereExp != nil {
+		constDef.WhereExp.Accept(symbolResolver)
+	}
+}
+
 func (symbolResolver *SymbolResolver) VisitFile(fileDef *parser.FileDefinition) {
 	for _, entry := range fileDef.Definitions {
 		entry.Accept(symbolResolver)
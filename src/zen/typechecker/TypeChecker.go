@@ -11,20 +11,28 @@ type TypeChecker struct {
 	errors        []parser.ParseError
 	typeStack     []parser.TypeNode
 	functionStack []*parser.FunctionInformation
+	instances     map[string]parser.TypeNode
+	whereDepth    int
+	module        string
+	exports       map[string]*VariableScope
+	constants     map[*VariableReference]*parser.ConstantDefinition
 }
 
 type VariableReference struct {
-	Type parser.TypeNode
+	Type      parser.TypeNode
+	IsMutable bool
 }
 
 type TypeReference struct {
-	Type parser.TypeNode
+	Type       parser.TypeNode
+	Parameters []*parser.TypeParameterType
 }
 
 type VariableScope struct {
-	parentScope *VariableScope
-	variableMap map[string]*VariableReference
-	typeMap     map[string]*TypeReference
+	parentScope     *VariableScope
+	variableMap     map[string]*VariableReference
+	typeMap         map[string]*TypeReference
+	isFunctionScope bool
 }
 
 func (typeChecker *TypeChecker) pushFunctionInfo(fnInfo *parser.FunctionInformation) {
@@ -44,9 +52,22 @@ func (typeChecker *TypeChecker) peekFunctionInfo() *parser.FunctionInformation {
 }
 
 func (scope *VariableScope) initializeDefaultTypes() {
-	scope.typeMap["i32"] = &TypeReference{parser.NewIntegerType(32, true)}
+	scope.typeMap["i8"] = &TypeReference{parser.NewIntegerType(8, true), nil}
+	scope.typeMap["i16"] = &TypeReference{parser.NewIntegerType(16, true), nil}
+	scope.typeMap["i32"] = &TypeReference{parser.NewIntegerType(32, true), nil}
+	scope.typeMap["i64"] = &TypeReference{parser.NewIntegerType(64, true), nil}
 
-	scope.typeMap["bool"] = &TypeReference{parser.NewBooleanType()}
+	scope.typeMap["u8"] = &TypeReference{parser.NewIntegerType(8, false), nil}
+	scope.typeMap["u16"] = &TypeReference{parser.NewIntegerType(16, false), nil}
+	scope.typeMap["u32"] = &TypeReference{parser.NewIntegerType(32, false), nil}
+	scope.typeMap["u64"] = &TypeReference{parser.NewIntegerType(64, false), nil}
+
+	scope.typeMap["bool"] = &TypeReference{parser.NewBooleanType(), nil}
+
+	scope.typeMap["void"] = &TypeReference{parser.NewStructureTypeType(nil), nil}
+
+	scope.variableMap["Len"] = &VariableReference{parser.NewBuiltinFunctionType("Len"), false}
+	scope.variableMap["old"] = &VariableReference{parser.NewBuiltinFunctionType("old"), false}
 }
 
 func (typeChecker *TypeChecker) createScope() *VariableScope {
@@ -60,6 +81,7 @@ func (typeChecker *TypeChecker) createScope() *VariableScope {
 		topScope,
 		make(map[string]*VariableReference),
 		make(map[string]*TypeReference),
+		false,
 	}
 
 	typeChecker.scopes = append(typeChecker.scopes, result)
@@ -85,6 +107,21 @@ func (typeChecker *TypeChecker) peekScope() *VariableScope {
 	}
 }
 
+// Checks if a variable is defined outside of the function it is used in
+func (typeChecker *TypeChecker) isCapturedVariable(name string) bool {
+	for index := len(typeChecker.scopes) - 1; index >= 0; index = index - 1 {
+		if _, ok := typeChecker.scopes[index].variableMap[name]; ok {
+			return false
+		}
+
+		if typeChecker.scopes[index].isFunctionScope {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (typeChecker *TypeChecker) findType(name string) *TypeReference {
 	for index := len(typeChecker.scopes) - 1; index >= 0; index = index - 1 {
 		resultCheck, ok := typeChecker.scopes[index].typeMap[name]
@@ -109,6 +146,16 @@ func (typeChecker *TypeChecker) findVariable(name string) *VariableReference {
 	return nil
 }
 
+// Integers convert to any other integer type. The constraint checker makes
+// sure the value fits in the range of the new type
+func canAssign(to parser.TypeNode, from parser.TypeNode) bool {
+	if to.GetNodeType() == parser.IntegerNodeType && from.GetNodeType() == parser.IntegerNodeType {
+		return true
+	}
+
+	return to.CanAssignFrom(from)
+}
+
 func (typeChecker *TypeChecker) reportError(at tokenizer.SourceLocation, message string) {
 	typeChecker.errors = append(typeChecker.errors, parser.CreateError(at, message))
 }
@@ -131,7 +178,7 @@ func (typeChecker *TypeChecker) acceptSubType(statement parser.ParseNode) parser
 }
 
 func CreateTypeChecker() *TypeChecker {
-	return &TypeChecker{}
+	return &TypeChecker{nil, nil, nil, nil, make(map[string]parser.TypeNode), 0, "", make(map[string]*VariableScope), make(map[*VariableReference]*parser.ConstantDefinition)}
 }
 
 func (typeChecker *TypeChecker) VisitVoidExpression(expr *parser.VoidExpression) {
@@ -142,7 +189,14 @@ func (typeChecker *TypeChecker) VisitIdentifier(id *parser.Identifier) {
 	variableReference := typeChecker.findVariable(id.Token.Value)
 
 	if variableReference != nil {
+		// lambdas only see the values variables had when they were
+		// created so they can't use variables that change after that
+		if variableReference.IsMutable && typeChecker.isCapturedVariable(id.Token.Value) {
+			typeChecker.reportError(id.Token.At, "Lambdas cannot capture mutable variable '"+id.Token.Value+"'")
+		}
+
 		id.Type = variableReference.Type
+		id.Constant = typeChecker.constants[variableReference]
 		typeChecker.pushType(variableReference.Type)
 	} else {
 		typeChecker.reportError(id.Token.At, "Variable '"+id.Token.Value+"' is not defined")
@@ -171,6 +225,28 @@ func (typeChecker *TypeChecker) VisitUnaryExpression(exp *parser.UnaryExpression
 			}
 			typeChecker.pushType(&parser.UndefinedType{})
 		}
+	} else if exp.Operator.TokenType == tokenizer.NotToken {
+		var subType = typeChecker.acceptSubType(exp.Expr)
+
+		if subType.GetNodeType() != parser.BooleanNodeType && subType.GetNodeType() != parser.UndefinedNodeType {
+			typeChecker.reportError(exp.Operator.At, "Could not apply operator '!' to type ")
+		}
+
+		exp.Type = &parser.BooleanType{}
+		typeChecker.pushType(exp.Type)
+	} else if exp.Operator.Value == "post" {
+		var subType = typeChecker.acceptSubType(exp.Expr)
+
+		if typeChecker.whereDepth == 0 {
+			typeChecker.reportError(exp.Operator.At, "post can only be used in a where expression")
+		}
+
+		if subType.GetNodeType() != parser.BooleanNodeType && subType.GetNodeType() != parser.UndefinedNodeType {
+			typeChecker.reportError(exp.Expr.Begin(), "A post condition must evaluate to a boolean")
+		}
+
+		exp.Type = &parser.BooleanType{}
+		typeChecker.pushType(exp.Type)
 	} else {
 		typeChecker.reportError(exp.Operator.At, "Unknown operator '"+exp.Operator.Value+"'")
 		typeChecker.pushType(&parser.UndefinedType{})
@@ -181,7 +257,9 @@ func (typeChecker *TypeChecker) VisitPropertyExpression(exp *parser.PropertyExpr
 	var structType = typeChecker.acceptSubType(exp.Left)
 	var subType = structType.GetSubType(exp.Property.Value)
 
-	if !parser.IsUndefined(structType) && parser.IsUndefined(subType) {
+	if _, ok := structType.(*parser.UnionTypeType); ok {
+		typeChecker.reportError(exp.Property.At, "Field '"+exp.Property.Value+"' can only be used in a match arm for its variant")
+	} else if !parser.IsUndefined(structType) && parser.IsUndefined(subType) {
 		typeChecker.reportError(exp.Property.At, "Property '"+exp.Property.Value+"' does not exist on type")
 	}
 
@@ -189,17 +267,34 @@ func (typeChecker *TypeChecker) VisitPropertyExpression(exp *parser.PropertyExpr
 	typeChecker.pushType(subType)
 }
 
+func isArithmeticOperator(tokenType tokenizer.TokenType) bool {
+	return tokenType == tokenizer.AddToken ||
+		tokenType == tokenizer.MinusToken ||
+		tokenType == tokenizer.MultiplyToken ||
+		tokenType == tokenizer.DivideToken ||
+		tokenType == tokenizer.ModToken ||
+		tokenType == tokenizer.WrapAddToken ||
+		tokenType == tokenizer.WrapMinusToken ||
+		tokenType == tokenizer.WrapMultiplyToken
+}
+
+// Arithmetic is done in the operand type that can hold both operands
+func arithmeticResultType(left *parser.IntegerType, right *parser.IntegerType) *parser.IntegerType {
+	if !left.ContainsRange(right) && right.ContainsRange(left) {
+		return right
+	}
+
+	return left
+}
+
 func (typeChecker *TypeChecker) VisitBinaryExpression(exp *parser.BinaryExpression) {
 	var leftType = typeChecker.acceptSubType(exp.Left)
 	var rightType = typeChecker.acceptSubType(exp.Right)
 
-	if exp.Operator.TokenType == tokenizer.AddToken ||
-		exp.Operator.TokenType == tokenizer.MinusToken ||
-		exp.Operator.TokenType == tokenizer.MultiplyToken ||
-		exp.Operator.TokenType == tokenizer.DivideToken {
+	if isArithmeticOperator(exp.Operator.TokenType) {
 		if leftType.GetNodeType() == rightType.GetNodeType() && leftType.GetNodeType() == parser.IntegerNodeType {
-			exp.Type = leftType
-			typeChecker.pushType(leftType)
+			exp.Type = arithmeticResultType(leftType.(*parser.IntegerType), rightType.(*parser.IntegerType))
+			typeChecker.pushType(exp.Type)
 		} else {
 			if leftType.GetNodeType() != parser.UndefinedNodeType && rightType.GetNodeType() != parser.UndefinedNodeType {
 				typeChecker.reportError(exp.Operator.At, "Operator '"+exp.Operator.Value+"' cannot be applied to given types")
@@ -255,6 +350,7 @@ func (typeChecker *TypeChecker) VisitStructureExpression(exp *parser.StructureEx
 		subEntries = append(subEntries, parser.NewStructureNamedEntryType(
 			name,
 			subType,
+			false,
 		))
 	}
 
@@ -263,6 +359,184 @@ func (typeChecker *TypeChecker) VisitStructureExpression(exp *parser.StructureEx
 	typeChecker.pushType(structureType)
 }
 
+// Structure literals take the type of the place they are used in. Entries
+// are matched to fields by name if they have one and by position otherwise.
+// Returns the type to check the value against, undefined if the literal
+// could not be given the target type
+func (typeChecker *TypeChecker) useContextualType(value parser.Expression, valueType parser.TypeNode, targetType parser.TypeNode) parser.TypeNode {
+	asLiteral, ok := value.(*parser.StructureExpression)
+	targetStructure, targetOk := targetType.(*parser.StructureTypeType)
+
+	if !ok || !targetOk || asLiteral.Type == nil {
+		return valueType
+	}
+
+	if len(asLiteral.Entries) != len(targetStructure.Entries) {
+		typeChecker.reportError(value.Begin(), fmt.Sprintf(
+			"Expected %d entries got %d",
+			len(targetStructure.Entries),
+			len(asLiteral.Entries),
+		))
+		return &parser.UndefinedType{}
+	}
+
+	var fits = true
+	var given = make(map[string]bool)
+
+	for index, entry := range asLiteral.Entries {
+		var field = targetStructure.Entries[index]
+
+		if (entry.Name != nil) != (asLiteral.Entries[0].Name != nil) {
+			typeChecker.reportError(entry.Expr.Begin(), "Entries of a structure must either all be named or all be unnamed")
+			fits = false
+			continue
+		} else if entry.Name != nil {
+			field = findEntry(targetStructure, entry.Name.Value)
+
+			if field == nil {
+				typeChecker.reportError(entry.Name.At, "Field '"+entry.Name.Value+"' does not exist on type")
+				fits = false
+				continue
+			} else if given[field.Name] {
+				typeChecker.reportError(entry.Name.At, "Field '"+field.Name+"' is given more than once")
+				fits = false
+				continue
+			}
+		}
+
+		given[field.Name] = true
+
+		var entryType = typeChecker.useContextualType(entry.Expr, asLiteral.Type.Entries[index].Type, field.Type)
+
+		if parser.IsUndefined(entryType) {
+			fits = false
+		} else if !canAssign(field.Type, entryType) {
+			typeChecker.reportError(entry.Expr.Begin(), "Value is incompatible with the type of field '"+field.Name+"'")
+			fits = false
+		}
+	}
+
+	if !fits {
+		return &parser.UndefinedType{}
+	}
+
+	asLiteral.Type = targetStructure
+	return targetStructure
+}
+
+func callResultType(fnType *parser.FunctionTypeType) parser.TypeNode {
+	if len(fnType.Output.Entries) == 0 {
+		return &parser.VoidType{}
+	} else if len(fnType.Output.Entries) == 1 {
+		return fnType.Output.Entries[0].Type
+	} else {
+		return fnType.Output
+	}
+}
+
+func (typeChecker *TypeChecker) checkBuiltinCall(builtin *parser.BuiltinFunctionType, exp *parser.CallExpression, argumentTypes []parser.TypeNode) parser.TypeNode {
+	switch builtin.Name {
+	case "Len":
+		if len(argumentTypes) != 1 {
+			typeChecker.reportError(exp.Begin(), fmt.Sprintf("Expected 1 arguments got %d", len(argumentTypes)))
+		} else if argumentTypes[0].GetNodeType() != parser.ArrayNodeType {
+			if !parser.IsUndefined(argumentTypes[0]) {
+				typeChecker.reportError(exp.Arguments[0].Begin(), "Len can only be applied to an array")
+			}
+		}
+
+		return parser.NewIntegerType(32, false)
+	case "old":
+		if typeChecker.whereDepth == 0 {
+			typeChecker.reportError(exp.Begin(), "old can only be used in a where expression")
+		}
+
+		if len(argumentTypes) != 1 {
+			typeChecker.reportError(exp.Begin(), fmt.Sprintf("Expected 1 arguments got %d", len(argumentTypes)))
+			return &parser.UndefinedType{}
+		}
+
+		return argumentTypes[0]
+	}
+
+	return &parser.UndefinedType{}
+}
+
+func (typeChecker *TypeChecker) VisitCallExpression(exp *parser.CallExpression) {
+	var functionType = typeChecker.acceptSubType(exp.Function)
+	var argumentTypes []parser.TypeNode = nil
+
+	for _, argument := range exp.Arguments {
+		argumentTypes = append(argumentTypes, typeChecker.acceptSubType(argument))
+	}
+
+	asBuiltin, ok := functionType.(*parser.BuiltinFunctionType)
+
+	if ok {
+		exp.Type = typeChecker.checkBuiltinCall(asBuiltin, exp, argumentTypes)
+		typeChecker.pushType(exp.Type)
+		return
+	}
+
+	asGeneric, ok := functionType.(*parser.GenericFunctionType)
+
+	if ok {
+		functionType = typeChecker.inferTypeArguments(asGeneric, exp, argumentTypes)
+	}
+
+	asFunctionType, ok := functionType.(*parser.FunctionTypeType)
+
+	if !ok {
+		if !parser.IsUndefined(functionType) {
+			typeChecker.reportError(exp.Function.Begin(), "Expression is not a function")
+		}
+		typeChecker.pushType(&parser.UndefinedType{})
+		return
+	}
+
+	if len(asFunctionType.Input.Entries) != len(exp.Arguments) {
+		typeChecker.reportError(exp.Begin(), fmt.Sprintf(
+			"Expected %d arguments got %d",
+			len(asFunctionType.Input.Entries),
+			len(exp.Arguments),
+		))
+	} else {
+		for index, argumentType := range argumentTypes {
+			argumentType = typeChecker.useContextualType(exp.Arguments[index], argumentType, asFunctionType.Input.Entries[index].Type)
+
+			if !parser.IsUndefined(argumentType) && !canAssign(asFunctionType.Input.Entries[index].Type, argumentType) {
+				typeChecker.reportError(exp.Arguments[index].Begin(), "Argument type incompatible with function signature")
+			}
+		}
+	}
+
+	exp.FunctionType = asFunctionType
+	exp.Type = callResultType(asFunctionType)
+	typeChecker.pushType(exp.Type)
+}
+
+func (typeChecker *TypeChecker) VisitIndexExpression(exp *parser.IndexExpression) {
+	var arrayType = typeChecker.acceptSubType(exp.Left)
+	var indexType = typeChecker.acceptSubType(exp.Index)
+
+	if indexType.GetNodeType() != parser.IntegerNodeType && !parser.IsUndefined(indexType) {
+		typeChecker.reportError(exp.Index.Begin(), "Array index must be an integer")
+	}
+
+	asArray, ok := arrayType.(*parser.ArrayTypeType)
+
+	if ok {
+		exp.Type = asArray.ElementType
+	} else {
+		if !parser.IsUndefined(arrayType) {
+			typeChecker.reportError(exp.Left.Begin(), "Expression is not an array")
+		}
+		exp.Type = &parser.UndefinedType{}
+	}
+
+	typeChecker.pushType(exp.Type)
+}
+
 func (typeChecker *TypeChecker) VisitIf(ifStatement *parser.IfStatement) {
 	_, ok := typeChecker.acceptSubType(ifStatement.Expresssion).(*parser.BooleanType)
 
@@ -277,10 +551,77 @@ func (typeChecker *TypeChecker) VisitIf(ifStatement *parser.IfStatement) {
 	}
 }
 
+func (typeChecker *TypeChecker) VisitWhile(whileStatement *parser.WhileStatement) {
+	_, ok := typeChecker.acceptSubType(whileStatement.Condition).(*parser.BooleanType)
+
+	if !ok {
+		typeChecker.reportError(whileStatement.Condition.Begin(), "While expression must evaluate to boolean")
+	}
+
+	if whileStatement.Invariant != nil {
+		var invariantType = typeChecker.acceptSubType(whileStatement.Invariant)
+
+		if _, ok := invariantType.(*parser.BooleanType); !ok && !parser.IsUndefined(invariantType) {
+			typeChecker.reportError(whileStatement.Invariant.Begin(), "Loop invariant must evaluate to boolean")
+		}
+	}
+
+	if whileStatement.Decreases != nil {
+		var measureType = typeChecker.acceptSubType(whileStatement.Decreases)
+
+		if measureType.GetNodeType() != parser.IntegerNodeType && !parser.IsUndefined(measureType) {
+			typeChecker.reportError(whileStatement.Decreases.Begin(), "Loop measure must be an integer")
+		}
+	}
+
+	typeChecker.acceptSubType(whileStatement.Body)
+}
+
+func (typeChecker *TypeChecker) VisitForeach(foreach *parser.ForeachStatement) {
+	var collectionType = typeChecker.acceptSubType(foreach.Collection)
+	var elementType parser.TypeNode = &parser.UndefinedType{}
+
+	asArray, ok := collectionType.(*parser.ArrayTypeType)
+
+	if ok {
+		elementType = asArray.ElementType
+	} else if !parser.IsUndefined(collectionType) {
+		typeChecker.reportError(foreach.Collection.Begin(), "Expression is not an array")
+	}
+
+	var scope = typeChecker.createScope()
+	scope.variableMap[foreach.Element.Value] = &VariableReference{elementType, false}
+
+	if foreach.Index != nil {
+		scope.variableMap[foreach.Index.Value] = &VariableReference{parser.NewIntegerType(32, false), false}
+	}
+
+	typeChecker.acceptSubType(foreach.Body)
+	typeChecker.popScope()
+}
+
 func (typeChecker *TypeChecker) VisitBody(body *parser.Body) {
+	typeChecker.createScope()
+
 	for _, statement := range body.Statements {
 		typeChecker.acceptSubType(statement)
 	}
+
+	typeChecker.popScope()
+
+	// everything after a statement that can't fall through is dead code
+	// unless it is marked as unreachable
+	for index, statement := range body.Statements {
+		if !parser.CanFallThrough(statement) && index+1 < len(body.Statements) {
+			var next = body.Statements[index+1]
+			var last = body.Statements[len(body.Statements)-1]
+
+			if _, isMarked := next.(*parser.UnreachableStatement); !isMarked {
+				typeChecker.errors = append(typeChecker.errors, parser.CreateSpanWarning(next.Begin(), last.End(), "Unreachable code"))
+			}
+			break
+		}
+	}
 }
 
 func (typeChecker *TypeChecker) VisitReturn(ret *parser.ReturnStatement) {
@@ -300,18 +641,192 @@ func (typeChecker *TypeChecker) VisitReturn(ret *parser.ReturnStatement) {
 	for index, expression := range ret.ExpressionList {
 		var returnType = typeChecker.acceptSubType(expression)
 
-		if forFunction != nil && !forFunction.ReturnType.Entries[index].Type.CanAssignFrom(returnType) {
+		if forFunction == nil || index >= len(forFunction.ReturnType.Entries) {
+			continue
+		}
+
+		returnType = typeChecker.useContextualType(expression, returnType, forFunction.ReturnType.Entries[index].Type)
+
+		if !parser.IsUndefined(returnType) && !canAssign(forFunction.ReturnType.Entries[index].Type, returnType) {
 			typeChecker.reportError(expression.Begin(), "Return type incomatible with function signature")
 		}
 	}
 }
 
+func (typeChecker *TypeChecker) VisitVarDef(varDef *parser.VariableDefinition) {
+	var topScope = typeChecker.peekScope()
+	var varType parser.TypeNode = nil
+
+	if varDef.TypeExp != nil {
+		varType = typeChecker.acceptSubType(varDef.TypeExp)
+	}
+
+	if varDef.Value != nil {
+		var valueType = typeChecker.acceptSubType(varDef.Value)
+
+		if varType != nil {
+			valueType = typeChecker.useContextualType(varDef.Value, valueType, varType)
+		}
+
+		if varType == nil {
+			varType = valueType
+
+			if valueType.GetNodeType() == parser.VoidNodeType {
+				typeChecker.reportError(varDef.Value.Begin(), "Cannot assign a void value to variable '"+varDef.Name.Value+"'")
+			}
+		} else if !parser.IsUndefined(varType) && !parser.IsUndefined(valueType) && !canAssign(varType, valueType) {
+			typeChecker.reportError(varDef.Value.Begin(), "Value is incompatible with the type of variable '"+varDef.Name.Value+"'")
+		}
+	}
+
+	_, alreadyDefined := topScope.variableMap[varDef.Name.Value]
+
+	if alreadyDefined {
+		typeChecker.reportError(varDef.Name.At, "Variable '"+varDef.Name.Value+"' is already defined")
+	}
+
+	varDef.Type = varType
+	topScope.variableMap[varDef.Name.Value] = &VariableReference{varType, varDef.IsMutable}
+}
+
+// The entries of a structure, like the outputs of a call, are bound to the
+// names in order
+func (typeChecker *TypeChecker) VisitDestructure(destructure *parser.DestructuringDefinition) {
+	var topScope = typeChecker.peekScope()
+	var valueType = typeChecker.acceptSubType(destructure.Value)
+	asStructure, ok := valueType.(*parser.StructureTypeType)
+
+	if !ok {
+		if !parser.IsUndefined(valueType) {
+			typeChecker.reportError(destructure.Value.Begin(), "Only structures can be destructured")
+		}
+	} else if len(asStructure.Entries) != len(destructure.Names) {
+		typeChecker.reportError(destructure.Begin(), fmt.Sprintf(
+			"Expected %d names got %d",
+			len(asStructure.Entries),
+			len(destructure.Names),
+		))
+	}
+
+	for index, name := range destructure.Names {
+		var nameType parser.TypeNode = &parser.UndefinedType{}
+
+		if ok && index < len(asStructure.Entries) {
+			nameType = asStructure.Entries[index].Type
+		}
+
+		_, alreadyDefined := topScope.variableMap[name.Value]
+
+		if alreadyDefined {
+			typeChecker.reportError(name.At, "Variable '"+name.Value+"' is already defined")
+		}
+
+		destructure.Types[index] = nameType
+		topScope.variableMap[name.Value] = &VariableReference{nameType, destructure.IsMutable}
+	}
+}
+
+func (typeChecker *TypeChecker) VisitAssignment(assignment *parser.AssignmentStatement) {
+	var valueType = typeChecker.acceptSubType(assignment.Value)
+
+	asIndex, ok := assignment.Target.(*parser.IndexExpression)
+
+	if ok {
+		var elementType = typeChecker.acceptSubType(asIndex)
+		valueType = typeChecker.useContextualType(assignment.Value, valueType, elementType)
+
+		if !parser.IsUndefined(elementType) && !parser.IsUndefined(valueType) && !canAssign(elementType, valueType) {
+			typeChecker.reportError(assignment.Value.Begin(), "Value is incompatible with the array element type")
+		}
+		return
+	}
+
+	asProperty, ok := assignment.Target.(*parser.PropertyExpression)
+
+	if ok {
+		typeChecker.checkFieldAssignment(assignment, asProperty, valueType)
+		return
+	}
+
+	asIdentifier, ok := assignment.Target.(*parser.Identifier)
+
+	if !ok {
+		typeChecker.reportError(assignment.Target.Begin(), "Invalid assignment target")
+		return
+	}
+
+	var targetType = typeChecker.acceptSubType(asIdentifier)
+	var variableReference = typeChecker.findVariable(asIdentifier.Token.Value)
+
+	if variableReference == nil {
+		return
+	}
+
+	valueType = typeChecker.useContextualType(assignment.Value, valueType, targetType)
+
+	if !variableReference.IsMutable {
+		typeChecker.reportError(asIdentifier.Begin(), "Cannot assign to immutable variable '"+asIdentifier.Token.Value+"'")
+	} else if !parser.IsUndefined(targetType) && !parser.IsUndefined(valueType) && !canAssign(targetType, valueType) {
+		typeChecker.reportError(assignment.Value.Begin(), "Value is incompatible with the type of variable '"+asIdentifier.Token.Value+"'")
+	}
+}
+
+func (typeChecker *TypeChecker) VisitAssert(assert *parser.AssertStatement) {
+	var expressionType = typeChecker.acceptSubType(assert.Expression)
+
+	if _, ok := expressionType.(*parser.BooleanType); !ok && !parser.IsUndefined(expressionType) {
+		if assert.IsAssumption {
+			typeChecker.reportError(assert.Expression.Begin(), "Assume expression must evaluate to boolean")
+		} else {
+			typeChecker.reportError(assert.Expression.Begin(), "Assert expression must evaluate to boolean")
+		}
+	}
+}
+
+func (typeChecker *TypeChecker) VisitUnreachable(unreachable *parser.UnreachableStatement) {
+
+}
+
+func findEntry(structure *parser.StructureTypeType, name string) *parser.StructureNamedEntryType {
+	for _, entry := range structure.Entries {
+		if entry.Name == name {
+			return entry
+		}
+	}
+
+	return nil
+}
+
+// Fields marked as mut can be changed through any reference to the structure
+func (typeChecker *TypeChecker) checkFieldAssignment(assignment *parser.AssignmentStatement, target *parser.PropertyExpression, valueType parser.TypeNode) {
+	var targetType = typeChecker.acceptSubType(target)
+	asStructure, ok := target.Left.GetType().(*parser.StructureTypeType)
+
+	if !ok || parser.IsUndefined(targetType) {
+		return
+	}
+
+	var entry = findEntry(asStructure, target.Property.Value)
+	valueType = typeChecker.useContextualType(assignment.Value, valueType, targetType)
+
+	if !entry.IsMutable {
+		typeChecker.reportError(target.Property.At, "Cannot assign to immutable field '"+entry.Name+"'")
+	} else if !entry.IsPublic && entry.Module != typeChecker.module {
+		typeChecker.reportError(target.Property.At, "Field '"+entry.Name+"' can only be modified inside of module '"+entry.Module+"'")
+	} else if !parser.IsUndefined(valueType) && !canAssign(targetType, valueType) {
+		typeChecker.reportError(assignment.Value.Begin(), "Value is incompatible with the type of field '"+entry.Name+"'")
+	}
+}
+
 func (typeChecker *TypeChecker) VisitNamedType(namedType *parser.NamedType) {
 	var typeResult = typeChecker.findType(namedType.Token.Value)
 
 	if typeResult == nil {
 		typeChecker.reportError(namedType.Begin(), fmt.Sprintf("Could not find type %s", namedType.Token.Value))
 		typeChecker.pushType(&parser.UndefinedType{})
+	} else if len(typeResult.Parameters) != 0 || len(namedType.TypeArguments) != 0 {
+		namedType.Type = typeChecker.instantiateNamedType(namedType, typeResult)
+		typeChecker.pushType(namedType.Type)
 	} else {
 		namedType.Type = typeResult.Type
 		typeChecker.pushType(typeResult.Type)
@@ -322,10 +837,19 @@ func (typeChecker *TypeChecker) VisitStructureType(structure *parser.StructureTy
 	var subEntries []*parser.StructureNamedEntryType
 
 	for _, entry := range structure.Entries {
+		var name = ""
+
+		if entry.Name != nil {
+			name = entry.Name.Value
+		}
+
 		var subType = &parser.StructureNamedEntryType{
-			entry.Name.Value,
+			name,
 			entry.UniqueId,
 			typeChecker.acceptSubType(entry.TypeExp),
+			entry.IsMutable,
+			entry.IsPublic,
+			typeChecker.module,
 		}
 		entry.Type = subType
 		subEntries = append(subEntries, subType)
@@ -344,13 +868,25 @@ func (typeChecker *TypeChecker) VisitFunctionType(fn *parser.FunctionType) {
 	inputAsStructure, ok := inputType.(*parser.StructureTypeType)
 
 	if !ok {
-		typeChecker.reportError(fn.Input.Begin(), "Function input type must be a structure")
+		if !parser.IsUndefined(inputType) {
+			typeChecker.reportError(fn.Input.Begin(), "Function input type must be a structure")
+		}
+		inputAsStructure = parser.NewStructureTypeType(nil)
 	}
 
 	outputAsStructure, ok := outputType.(*parser.StructureTypeType)
-
-	if !ok {
-		typeChecker.reportError(fn.Output.Begin(), "Function output type must be a structure")
+	_, outputIsFunction := outputType.(*parser.FunctionTypeType)
+
+	if outputIsFunction {
+		// a => b => c chains to the right and returns a single function
+		outputAsStructure = parser.NewStructureTypeType([]*parser.StructureNamedEntryType{
+			parser.NewStructureNamedEntryType("", outputType, false),
+		})
+	} else if !ok {
+		if !parser.IsUndefined(outputType) {
+			typeChecker.reportError(fn.Output.Begin(), "Function output type must be a structure")
+		}
+		outputAsStructure = parser.NewStructureTypeType(nil)
 	}
 
 	var result = parser.NewFunctionTypeType(inputAsStructure, outputAsStructure)
@@ -359,6 +895,14 @@ func (typeChecker *TypeChecker) VisitFunctionType(fn *parser.FunctionType) {
 	typeChecker.pushType(result)
 }
 
+func (typeChecker *TypeChecker) VisitArrayType(array *parser.ArrayType) {
+	var elementType = typeChecker.acceptSubType(array.ElementType)
+	var result = parser.NewArrayTypeType(elementType)
+
+	array.Type = result
+	typeChecker.pushType(result)
+}
+
 func (typeChecker *TypeChecker) VisitWhereType(where *parser.WhereType) {
 	var whereScope = typeChecker.createScope()
 
@@ -366,13 +910,13 @@ func (typeChecker *TypeChecker) VisitWhereType(where *parser.WhereType) {
 	containedType.SetWhereExpression(where.WhereExp)
 	typeChecker.pushType(containedType)
 
-	whereScope.variableMap["self"] = &VariableReference{containedType}
+	whereScope.variableMap["self"] = &VariableReference{containedType, false}
 
 	asStruct, ok := containedType.(*parser.StructureTypeType)
 
 	if ok {
 		for _, entry := range asStruct.Entries {
-			whereScope.variableMap[entry.Name] = &VariableReference{entry.Type}
+			whereScope.variableMap[entry.Name] = &VariableReference{entry.Type, false}
 		}
 	}
 
@@ -380,15 +924,17 @@ func (typeChecker *TypeChecker) VisitWhereType(where *parser.WhereType) {
 
 	if ok {
 		for _, input := range asFn.Input.Entries {
-			whereScope.variableMap[input.Name] = &VariableReference{input.Type}
+			whereScope.variableMap[input.Name] = &VariableReference{input.Type, false}
 		}
 
 		for _, output := range asFn.Output.Entries {
-			whereScope.variableMap[output.Name] = &VariableReference{output.Type}
+			whereScope.variableMap[output.Name] = &VariableReference{output.Type, false}
 		}
 	}
 
+	typeChecker.whereDepth = typeChecker.whereDepth + 1
 	_, ok = typeChecker.acceptSubType(where.WhereExp).(*parser.BooleanType)
+	typeChecker.whereDepth = typeChecker.whereDepth - 1
 
 	if !ok {
 		typeChecker.reportError(where.Begin(), "Where expression must evaluate to a boolean")
@@ -399,18 +945,29 @@ func (typeChecker *TypeChecker) VisitWhereType(where *parser.WhereType) {
 
 func (typeChecker *TypeChecker) VisitTypeDef(typeDef *parser.TypeDefinition) {
 	typeChecker.createScope()
+	var typeParameters = typeChecker.declareTypeParameters(typeDef.TypeParameters)
 	var defType = typeChecker.acceptSubType(typeDef.TypeExp)
 	typeChecker.popScope()
 	typeDef.Type = defType
 	var topScope = typeChecker.peekScope()
-	topScope.typeMap[typeDef.Name.Value] = &TypeReference{defType}
+	topScope.typeMap[typeDef.Name.Value] = &TypeReference{defType, typeParameters}
+
+	if asUnion, ok := defType.(*parser.UnionTypeType); ok {
+		typeChecker.declareVariantConstructors(asUnion, typeParameters)
+	}
 }
 
 func (typeChecker *TypeChecker) VisitFunction(fn *parser.Function) {
 	var topScope = typeChecker.createScope()
+	topScope.isFunctionScope = true
 
-	var fnType = typeChecker.acceptSubType(fn.TypeExp)
-	asFunctionType, ok := fnType.(*parser.FunctionTypeType)
+	var asFunctionType = fn.Type
+	var ok = asFunctionType != nil
+
+	if !ok {
+		var fnType = typeChecker.acceptSubType(fn.TypeExp)
+		asFunctionType, ok = fnType.(*parser.FunctionTypeType)
+	}
 
 	if !ok {
 		typeChecker.reportError(fn.TypeExp.Begin(), "Function must be a function type")
@@ -424,11 +981,11 @@ func (typeChecker *TypeChecker) VisitFunction(fn *parser.Function) {
 		returnType = asFunctionType.Output
 
 		for _, subType := range asFunctionType.Input.Entries {
-			topScope.variableMap[subType.Name] = &VariableReference{subType.Type}
+			topScope.variableMap[subType.Name] = &VariableReference{subType.Type, false}
 		}
 
 		for _, subType := range asFunctionType.Output.Entries {
-			topScope.variableMap[subType.Name] = &VariableReference{subType.Type}
+			topScope.variableMap[subType.Name] = &VariableReference{subType.Type, false}
 		}
 	}
 
@@ -438,6 +995,10 @@ func (typeChecker *TypeChecker) VisitFunction(fn *parser.Function) {
 
 	typeChecker.acceptSubType(fn.Body)
 
+	if len(returnType.Entries) > 0 && parser.CanFallThrough(fn.Body) {
+		typeChecker.reportError(fn.Body.CloseAt(), "Function can reach the end of its body without returning a value")
+	}
+
 	typeChecker.popScope()
 	typeChecker.popFunctionInfo()
 
@@ -445,18 +1006,94 @@ func (typeChecker *TypeChecker) VisitFunction(fn *parser.Function) {
 	typeChecker.pushType(asFunctionType)
 }
 
+// A generic function is only a value once its type parameters are known so
+// its name refers to the signature the parameters are inferred from
+func functionValueType(fnType parser.TypeNode, typeParameters []*parser.TypeParameterType) parser.TypeNode {
+	asFunctionType, ok := fnType.(*parser.FunctionTypeType)
+
+	if ok && len(typeParameters) != 0 {
+		return parser.NewGenericFunctionType(asFunctionType, typeParameters)
+	}
+
+	return fnType
+}
+
 func (typeChecker *TypeChecker) VisitFnDef(fnDef *parser.FunctionDefinition) {
+	typeChecker.createScope()
+	var typeParameters = typeChecker.declareTypeParameters(fnDef.TypeParameters)
 	var fnType = typeChecker.acceptSubType(fnDef.Function)
+	typeChecker.popScope()
+
 	var topScope = typeChecker.peekScope()
-	topScope.variableMap[fnDef.Name.Value] = &VariableReference{fnType}
+	topScope.variableMap[fnDef.Name.Value] = &VariableReference{functionValueType(fnType, typeParameters), false}
 }
 
-func (typeChecker *TypeChecker) VisitFile(fileDef *parser.FileDefinition) {
-	for _, definition := range fileDef.Definitions {
-		typeChecker.acceptSubType(definition)
+// Function signatures are resolved before any function bodies are checked
+// so functions can call each other regardless of the order they are defined
+func (typeChecker *TypeChecker) declareFunction(fnDef *parser.FunctionDefinition) {
+	typeChecker.createScope()
+	var typeParameters = typeChecker.declareTypeParameters(fnDef.TypeParameters)
+	var fnType = typeChecker.acceptSubType(fnDef.Function.TypeExp)
+	typeChecker.popScope()
+
+	asFunctionType, ok := fnType.(*parser.FunctionTypeType)
+
+	if ok {
+		fnDef.Function.Type = asFunctionType
+		typeChecker.peekScope().variableMap[fnDef.Name.Value] = &VariableReference{functionValueType(asFunctionType, typeParameters), false}
 	}
 }
 
+// Constants, types and function signatures of every file are declared
+// before any function body is checked so files can use each other's
+// definitions. Constants come first so types can use them in contracts
+func (typeChecker *TypeChecker) checkDefinitions(files []*parser.FileDefinition) {
+	for _, fileDef := range files {
+		for _, definition := range fileDef.Definitions {
+			asConstDef, ok := definition.(*parser.ConstantDefinition)
+
+			if ok {
+				asConstDef.Accept(typeChecker)
+			}
+		}
+	}
+
+	for _, fileDef := range files {
+		for _, definition := range fileDef.Definitions {
+			asTypeDef, ok := definition.(*parser.TypeDefinition)
+
+			if ok {
+				typeChecker.acceptSubType(asTypeDef)
+			}
+		}
+	}
+
+	for _, fileDef := range files {
+		for _, definition := range fileDef.Definitions {
+			asFnDef, ok := definition.(*parser.FunctionDefinition)
+
+			if ok {
+				typeChecker.declareFunction(asFnDef)
+			}
+		}
+	}
+
+	for _, fileDef := range files {
+		for _, definition := range fileDef.Definitions {
+			_, isTypeDef := definition.(*parser.TypeDefinition)
+			_, isConstDef := definition.(*parser.ConstantDefinition)
+
+			if !isTypeDef && !isConstDef {
+				typeChecker.acceptSubType(definition)
+			}
+		}
+	}
+}
+
+func (typeChecker *TypeChecker) VisitFile(fileDef *parser.FileDefinition) {
+	typeChecker.checkDefinitions([]*parser.FileDefinition{fileDef})
+}
+
 func CheckTypes(parseNode parser.ParseNode) []parser.ParseError {
 	var checker = CreateTypeChecker()
 	checker.createScope().initializeDefaultTypes()
@@ -1,6 +1,7 @@
 package typechecker
 
 import (
+	"strings"
 	"testing"
 	"zen/parser"
 	"zen/source"
@@ -20,3 +21,711 @@ func TestNumberType(t *testing.T) {
 
 	test.Assert(t, expr.GetType().CanAssignFrom(parser.NewIntegerType(32, true)), "Number literals evaluate to integers")
 }
+
+func checkSourceTypes(t *testing.T, sourceString string) []parser.ParseError {
+	var file, errors = parser.Parse(source.SourceFromString(sourceString))
+
+	if len(errors) > 0 {
+		for _, err := range errors {
+			t.Log(parser.FormatError(err))
+		}
+		t.Fatalf("Error parsing source")
+	}
+
+	return CheckTypes(file)
+}
+
+func TestVariableDefinitionTypes(t *testing.T) {
+	var errors = checkSourceTypes(t, `
+		func Test[a: i32] => [r: i32] {
+			let b = a
+			var c: i32 = b
+			c = a
+			return c
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Variables should type check")
+
+	errors = checkSourceTypes(t, `
+		func Test[a: i32] => [r: i32] {
+			let b = a
+			b = a
+			return b
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Let variables should be immutable")
+
+	errors = checkSourceTypes(t, `
+		func Test[a: i32] => [r: i32] {
+			var b: bool = a
+			return a
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Variable values should match declared type")
+
+	errors = checkSourceTypes(t, `
+		func Test[a: i32] => [r: i32] {
+			if (a > 0) {
+				let b = a
+			}
+			return b
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Variables should be scoped to their body")
+}
+
+func TestCallExpressionTypes(t *testing.T) {
+	var errors = checkSourceTypes(t, `
+		func Test[a: i32] => [r: i32] {
+			return Min(a, 1)
+		}
+
+		func Min[a: i32, b: i32] => [r: i32] {
+			return a
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Functions should be callable before their definition")
+
+	errors = checkSourceTypes(t, `
+		func Min[a: i32, b: i32] => [r: i32] {
+			return Min(a)
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Argument count should match")
+
+	errors = checkSourceTypes(t, `
+		func Min[a: i32, b: i32] => [r: i32] {
+			return Min(a, a > b)
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Argument types should match")
+
+	errors = checkSourceTypes(t, `
+		func Min[a: i32, b: i32] => [r: i32] {
+			return a(b)
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Only functions can be called")
+}
+
+func TestArrayTypes(t *testing.T) {
+	var errors = checkSourceTypes(t, `
+		func First[data: []i32] => [r: i32] {
+			var copy: []i32 = data
+			copy[0] = data[Len(data) - 1]
+			return copy[0]
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Arrays should type check")
+
+	errors = checkSourceTypes(t, `
+		func First[data: []i32, flag: bool] => [r: i32] {
+			return data[flag]
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Indices should be integers")
+
+	errors = checkSourceTypes(t, `
+		func First[data: i32] => [r: i32] {
+			return data[0]
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Only arrays can be indexed")
+
+	errors = checkSourceTypes(t, `
+		func First[data: i32] => [r: i32] {
+			let length = Len(data)
+			return data
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Len only applies to arrays")
+}
+
+func TestIntegerTypes(t *testing.T) {
+	var errors = checkSourceTypes(t, `
+		func Widen[a: i8, b: u16] => [r: i64] {
+			let c: u64 = b
+			return a
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "All integer types should resolve")
+
+	errors = checkSourceTypes(t, `
+		func Narrow[a: i32] => [r: u8] {
+			let b: i16 = a
+			return b
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Integers convert between types")
+
+	errors = checkSourceTypes(t, `
+		func Narrow[a: i32] => [r: bool] {
+			return a
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Integers are not booleans")
+}
+
+func TestGenericTypes(t *testing.T) {
+	var errors = checkSourceTypes(t, `
+		type Box(T) [value: T]
+
+		func Unbox(T)[box: Box(T)] => [r: T] {
+			return box.value
+		}
+
+		func Use[box: Box(i32)] => [r: i32] {
+			return Unbox(box) + 1
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Generic types should be instantiated")
+
+	errors = checkSourceTypes(t, `
+		type Box(T) [value: T]
+
+		func Use[box: Box(bool)] => [r: i32] {
+			return box.value
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Type arguments should be substituted")
+
+	errors = checkSourceTypes(t, `
+		type Box(T) [value: T]
+
+		func Use[box: Box(i32, i32), other: Box] => [r: i32] {
+			return 0
+		}
+	`)
+	test.Assert(t, len(errors) == 2, "Type argument count should match")
+
+	errors = checkSourceTypes(t, `
+		func Default(T)[] => [r: T] {
+			return r
+		}
+
+		func Use[] => [r: i32] {
+			return Default()
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Type parameters should be inferred from arguments")
+}
+
+func TestFieldAssignment(t *testing.T) {
+	var errors = checkSourceTypes(t, `
+		type Counter [mut count: u32, limit: u32]
+
+		func Reset[counter: Counter] => [] {
+			counter.count = counter.limit
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Mutable fields should be assignable")
+
+	errors = checkSourceTypes(t, `
+		type Counter [mut count: u32, limit: u32]
+
+		func Reset[counter: Counter] => [] {
+			counter.limit = 0
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Immutable fields should not be assignable")
+
+	errors = checkSourceTypes(t, `
+		type Counter [mut count: u32, limit: u32]
+
+		func Reset[counter: Counter, flag: bool] => [] {
+			counter.count = flag
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Assigned values should match the field type")
+}
+
+func TestOldAndPost(t *testing.T) {
+	var errors = checkSourceTypes(t, `
+		type Counter [mut count: u32]
+
+		func Increment[counter: Counter] => [] where post counter.count == old(counter.count) + 1 {
+			counter.count = counter.count + 1
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "old and post should be allowed in where expressions")
+
+	errors = checkSourceTypes(t, `
+		type Counter [mut count: u32]
+
+		func Get[counter: Counter] => [r: u32] {
+			return old(counter.count)
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "old should only be allowed in where expressions")
+
+	errors = checkSourceTypes(t, `
+		type Counter [mut count: u32]
+
+		func Increment[counter: Counter] => [] where post counter.count {
+			counter.count = counter.count + 1
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "post should be followed by a condition")
+}
+
+func TestWhileTypes(t *testing.T) {
+	var errors = checkSourceTypes(t, `
+		func Count[n: u32] => [r: u32] {
+			var i: u32 = 0
+			while (i < n) invariant i <= n decreases n - i {
+				i = i + 1
+			}
+			return i
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "While loops should type check")
+
+	errors = checkSourceTypes(t, `
+		func Count[n: u32] => [r: u32] {
+			var i: u32 = 0
+			while (n) invariant i decreases i < n {
+				i = i + 1
+			}
+			return i
+		}
+	`)
+	test.Assert(t, len(errors) == 3, "Conditions and invariants should be booleans and measures integers")
+}
+
+func TestForeachTypes(t *testing.T) {
+	var errors = checkSourceTypes(t, `
+		func Sum[values: []i32] => [r: i32] {
+			var total: i32 = 0
+			foreach value, index in values {
+				total = total + value + values[index]
+			}
+			foreach total += in values
+			return total
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Foreach loops should define their element and index")
+
+	errors = checkSourceTypes(t, `
+		func Sum[count: i32] => [r: i32] {
+			foreach value in count {
+			}
+			return count
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Foreach loops should only iterate over arrays")
+
+	errors = checkSourceTypes(t, `
+		func Sum[values: []i32] => [r: i32] {
+			foreach value, index in values {
+				value = 1
+			}
+			return 0
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Loop elements should not be assignable")
+}
+
+func TestLambdas(t *testing.T) {
+	var errors = checkSourceTypes(t, `
+		func Apply[step: [a: u8] => [r: u8], x: u8] => [r: u8] {
+			return step(x)
+		}
+
+		func Increment[x: u8] => [r: u8] {
+			let offset: u8 = 1
+			return Apply([a: u8] => [r: u8] { return a +% offset }, x)
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Lambdas should be function values")
+
+	errors = checkSourceTypes(t, `
+		func Increment[x: u8] => [r: u8] {
+			let step = [a: u8] => [r: u8] { return a +% 1 }
+			return step(x, x)
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Calls through function values should check their arguments")
+
+	errors = checkSourceTypes(t, `
+		func Increment[x: u8] => [r: u8] {
+			var offset: u8 = 1
+			let step = [a: u8] => [r: u8] { return a +% offset }
+			return step(x)
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Lambdas should not capture mutable variables")
+
+	errors = checkSourceTypes(t, `
+		func MakeStep void => [u8] => [u8] {
+			return [a: u8] => [u8] { return a +% 1 }
+		}
+
+		func Increment[x: u8] => [r: u8] {
+			let step = MakeStep()
+			return step(x)
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "A chained function type should return a single function")
+}
+
+func TestStructureLiterals(t *testing.T) {
+	var rangeType = `
+		type Range [
+			Min: i32,
+			Max: i32,
+		] where Min <= Max
+	`
+
+	var errors = checkSourceTypes(t, rangeType+`
+		func MakeRange[a: u8, b: i32] => [result: Range] {
+			let named: Range = [Max: b, Min: a]
+			return [a, b]
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Literals should take the type they are used as")
+
+	errors = checkSourceTypes(t, rangeType+`
+		func MakeRange[a: i32] => [result: Range] {
+			return [a]
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Literals should have an entry for each field")
+
+	errors = checkSourceTypes(t, rangeType+`
+		func MakeRange[a: i32] => [result: Range] {
+			return [Min: a, Width: a]
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Named entries should exist on the type")
+
+	errors = checkSourceTypes(t, rangeType+`
+		func MakeRange[a: i32] => [result: Range] {
+			return [Min: a, a]
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Named and unnamed entries should not be mixed")
+
+	errors = checkSourceTypes(t, rangeType+`
+		func MakeRange[a: bool] => [result: Range] {
+			return [a, 1]
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Entries should be checked against their field")
+}
+
+func TestDestructuring(t *testing.T) {
+	var bisect = `
+		type Range [
+			Min: i32,
+			Max: i32,
+		]
+
+		func Bisect[range: Range, at: i32] => [a: Range, b: Range] {
+			return [range.Min, at], [at, range.Max]
+		}
+	`
+
+	var errors = checkSourceTypes(t, bisect+`
+		func Split[range: Range, at: i32] => [r: i32] {
+			let [lo, hi] = Bisect(range, at)
+			let [min, max] = hi
+			return lo.Max + Bisect(range, at).b.Min + min
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Destructuring should bind the outputs of a call")
+
+	errors = checkSourceTypes(t, bisect+`
+		func Split[range: Range, at: i32] => [r: i32] {
+			let [lo] = Bisect(range, at)
+			return at
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Destructuring should bind every output")
+
+	errors = checkSourceTypes(t, bisect+`
+		func Split[range: Range, at: i32] => [r: i32] {
+			let [lo, hi] = at
+			return at
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Only structures should be destructured")
+
+	errors = checkSourceTypes(t, bisect+`
+		func Split[range: Range, at: i32] => [r: i32] {
+			let [lo, hi] = Bisect(range, at)
+			lo = hi
+			return at
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Names bound by let should be immutable")
+}
+
+func TestUnions(t *testing.T) {
+	var shape = `
+		type Shape = Circle[r: i32] | Rect[w: i32, h: i32] | Empty
+	`
+
+	var errors = checkSourceTypes(t, shape+`
+		func Width[shape: Shape] => [r: i32] {
+			match shape {
+				Circle => { return shape.r + shape.r }
+				Rect => { return shape.w }
+				Empty => { return 0 }
+			}
+		}
+
+		func Make[x: i32] => [r: Shape] {
+			if (x > 0) {
+				return Circle(x)
+			} else {
+				return Empty()
+			}
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Variants should be constructed by name and used in match arms")
+
+	errors = checkSourceTypes(t, shape+`
+		func Width[shape: Shape] => [r: i32] {
+			return shape.r
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Fields should not be used outside of a match arm")
+
+	errors = checkSourceTypes(t, shape+`
+		func Width[shape: Shape] => [r: i32] {
+			match shape {
+				Circle => { return shape.r }
+				Rect => { return shape.r }
+				Empty => { return 0 }
+			}
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Fields should only be used in the arm for their variant")
+
+	errors = checkSourceTypes(t, shape+`
+		func Width[shape: Shape] => [r: i32] {
+			match shape {
+				Circle => { return shape.r }
+				Empty => { return 0 }
+			}
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Match should handle every variant")
+
+	errors = checkSourceTypes(t, shape+`
+		func Width[shape: Shape] => [r: i32] {
+			match shape {
+				Circle => { return shape.r }
+				Circle => { return 0 }
+				Square => { return 0 }
+				Rect => { return 0 }
+				Empty => { return 0 }
+			}
+		}
+	`)
+	test.Assert(t, len(errors) == 2, "Arms should name each variant of the union once")
+
+	errors = checkSourceTypes(t, shape+`
+		func Width[x: i32] => [r: i32] {
+			match x {
+			}
+			return 0
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Only unions should be matched")
+
+	errors = checkSourceTypes(t, shape+`
+		func Width[shape: Shape] => [r: i32] {
+			let width = match shape {
+				Circle => shape.r + shape.r,
+				Rect => shape.w,
+				Empty => 0,
+			}
+			return width
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Match expressions should have the value of their arms")
+
+	errors = checkSourceTypes(t, shape+`
+		func Width[shape: Shape] => [r: i32] {
+			let width = match shape { Circle => shape.r, Rect => shape.w > 0, Empty => 0 }
+			return width
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Arms of a match expression should have compatible types")
+
+	errors = checkSourceTypes(t, shape+`
+		func Width[shape: Shape] => [r: i32] {
+			return match shape { Circle => shape.r, Empty => 0 }
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Match expressions should handle every variant")
+}
+
+func TestBooleans(t *testing.T) {
+	var errors = checkSourceTypes(t, `
+		func Check[a: i32, b: i32, c: bool] => [r: bool] {
+			let ok = a < b
+			if (!ok && c) {
+				return !c
+			}
+			return ok
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Booleans should be stored and negated")
+
+	errors = checkSourceTypes(t, `
+		func Check[a: i32] => [r: bool] {
+			return !a
+		}
+	`)
+	test.Assert(t, len(errors) == 1, "Only booleans should be negated")
+}
+
+func TestAssertions(t *testing.T) {
+	var errors = checkSourceTypes(t, `
+		func Check[a: i32, b: bool] => [r: i32] {
+			assert a > 0
+			assume b
+			if (a > 10) {
+				unreachable
+			}
+			return a
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Assertions should take booleans")
+
+	errors = checkSourceTypes(t, `
+		func Check[a: i32] => [r: i32] {
+			assert a
+			assume a + 1
+			return a
+		}
+	`)
+	test.Assert(t, len(errors) == 2, "Assertions of integers should be reported")
+}
+
+func TestReturnPaths(t *testing.T) {
+	var errors = checkSourceTypes(t, `
+		func Abs[a: i32] => [r: i32] {
+			if (a > 0) {
+				return a
+			} else {
+				return -a
+			}
+		}
+
+		func Sign[a: i32] => [r: i32] {
+			if (a > 0) {
+				return 1
+			} else if (a < 0) {
+				return -1
+			}
+			return 0
+		}
+
+		func Positive[a: i32] => [r: i32] {
+			if (a > 0) {
+				return a
+			}
+			unreachable
+		}
+
+		func Log[a: i32] => [] {
+			if (a > 0) {
+				return
+			}
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Every path through these functions returns")
+
+	errors = checkSourceTypes(t, `
+		func Abs[a: i32] => [r: i32] {
+			if (a > 0) {
+				return a
+			}
+		}
+
+		func Loop[a: i32] => [r: i32] {
+			while (a > 0) {
+				return a
+			}
+		}
+	`)
+	test.Assert(t, len(errors) == 2, "Paths that reach the end of a function with outputs should be reported")
+
+	errors = checkSourceTypes(t, `
+		func Twice[a: i32] => [r: i32] {
+			return a, a
+		}
+
+		func Nothing[a: i32] => [r: i32] {
+			return
+		}
+	`)
+	test.Assert(t, len(errors) == 2, "The number of returned values should match the outputs")
+
+	errors = checkSourceTypes(t, `
+		func Twice[a: i32] => [r: i32] {
+			return a
+			let b = a + a
+			return b
+		}
+	`)
+	test.Assert(t, len(errors) == 1 && errors[0].IsWarning(), "Statements after a return should be reported as unreachable")
+
+	errors = checkSourceTypes(t, `
+		func Twice[a: i32] => [r: i32] {
+			return a
+			unreachable
+		}
+
+		func Abs[a: i32] => [r: i32] {
+			if (a > 0) {
+				return a
+			} else {
+				return -a
+			}
+			unreachable
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Statements marked unreachable should not be reported")
+
+	var source = `
+		func Abs[a: i32] => [r: i32] {
+			if (a > 0) {
+				return a
+			}
+		}
+	`
+	errors = checkSourceTypes(t, source)
+	test.Assert(t, len(errors) == 1 && errors[0].At.At == strings.LastIndex(source, "}"), "A missing return should be reported at the closing brace")
+}
+
+func TestConstants(t *testing.T) {
+	var errors = checkSourceTypes(t, `
+		type Lobby [players: i32] where players <= MaxPlayers
+
+		const MaxPlayers = 16
+		const MaxTeams: u8 = -MaxPlayers + MaxPlayers * 2 where MaxTeams > 0
+
+		func Teams[] => [r: i32] where r == MaxTeams {
+			return MaxTeams
+		}
+	`)
+	test.Assert(t, len(errors) == 0, "Constants should be usable in types, contracts and bodies")
+
+	errors = checkSourceTypes(t, `
+		const A = 10 / (5 - 5)
+		const B: bool = 1
+		const C = 1 where 2
+	`)
+	test.Assert(t, len(errors) == 3, "Constants should be integers known at compile time")
+
+	errors = checkSourceTypes(t, `
+		const MaxPlayers = 16
+		const Half = MaxPlayers / 2
+		const Odd = MaxPlayers % (Half - 3)
+	`)
+	test.Assert(t, len(errors) == 0, "Constants should be divisible by other constants")
+}
@@ -0,0 +1,138 @@
+package typechecker
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"zen/parser"
+	"zen/tokenizer"
+)
+
+// Constants can only be built from numbers, other constants and arithmetic
+// that can't wrap so their value can be folded. Division and modulo also
+// need a divisor that folds to a value other than zero
+func isConstantExpression(expression parser.Expression) bool {
+	if _, ok := expression.(*parser.Number); ok {
+		return true
+	}
+
+	if asIdentifier, ok := expression.(*parser.Identifier); ok {
+		return asIdentifier.Constant != nil
+	}
+
+	if asUnary, ok := expression.(*parser.UnaryExpression); ok {
+		return asUnary.Operator.TokenType == tokenizer.MinusToken && isConstantExpression(asUnary.Expr)
+	}
+
+	if asBinary, ok := expression.(*parser.BinaryExpression); ok {
+		var operator = asBinary.Operator.TokenType
+
+		if operator == tokenizer.DivideToken || operator == tokenizer.ModToken {
+			divisor, ok := foldConstant(asBinary.Right)
+			return ok && divisor != 0 && isConstantExpression(asBinary.Left)
+		}
+
+		return (operator == tokenizer.AddToken || operator == tokenizer.MinusToken || operator == tokenizer.MultiplyToken) &&
+			isConstantExpression(asBinary.Left) &&
+			isConstantExpression(asBinary.Right)
+	}
+
+	return false
+}
+
+// The value of a constant expression, if it can be computed without
+// overflowing or dividing by zero
+func foldConstant(expression parser.Expression) (value int64, ok bool) {
+	if asNumber, ok := expression.(*parser.Number); ok {
+		value, err := strconv.ParseInt(asNumber.Token.Value, 10, 64)
+		return value, err == nil
+	}
+
+	if asIdentifier, ok := expression.(*parser.Identifier); ok {
+		if asIdentifier.Constant == nil {
+			return 0, false
+		}
+
+		return foldConstant(asIdentifier.Constant.Value)
+	}
+
+	if asUnary, ok := expression.(*parser.UnaryExpression); ok {
+		value, ok := foldConstant(asUnary.Expr)
+
+		if !ok || asUnary.Operator.TokenType != tokenizer.MinusToken || value == math.MinInt64 {
+			return 0, false
+		}
+
+		return -value, true
+	}
+
+	asBinary, ok := expression.(*parser.BinaryExpression)
+
+	if !ok {
+		return 0, false
+	}
+
+	left, leftOk := foldConstant(asBinary.Left)
+	right, rightOk := foldConstant(asBinary.Right)
+
+	if !leftOk || !rightOk {
+		return 0, false
+	}
+
+	var result = new(big.Int)
+
+	switch asBinary.Operator.TokenType {
+	case tokenizer.AddToken:
+		result.Add(big.NewInt(left), big.NewInt(right))
+	case tokenizer.MinusToken:
+		result.Sub(big.NewInt(left), big.NewInt(right))
+	case tokenizer.MultiplyToken:
+		result.Mul(big.NewInt(left), big.NewInt(right))
+	case tokenizer.DivideToken:
+		if right == 0 {
+			return 0, false
+		}
+		result.Quo(big.NewInt(left), big.NewInt(right))
+	case tokenizer.ModToken:
+		if right == 0 {
+			return 0, false
+		}
+		result.Rem(big.NewInt(left), big.NewInt(right))
+	default:
+		return 0, false
+	}
+
+	return result.Int64(), result.IsInt64()
+}
+
+// A constant is declared before its where expression is checked so the
+// where expression can refer to it by name
+func (typeChecker *TypeChecker) VisitConst(constDef *parser.ConstantDefinition) {
+	var name = constDef.Name.Value
+	var valueType = typeChecker.acceptSubType(constDef.Value)
+	var constType = valueType
+
+	if constDef.TypeExp != nil {
+		constType = typeChecker.acceptSubType(constDef.TypeExp)
+	}
+
+	if !parser.IsUndefined(constType) && constType.GetNodeType() != parser.IntegerNodeType {
+		typeChecker.reportError(constDef.Name.At, "Constant '"+name+"' must be an integer")
+	} else if !parser.IsUndefined(valueType) && !isConstantExpression(constDef.Value) {
+		typeChecker.reportError(constDef.Value.Begin(), "The value of constant '"+name+"' must be known at compile time")
+	}
+
+	constDef.Type = constType
+
+	var reference = &VariableReference{constType, false}
+	typeChecker.peekScope().variableMap[name] = reference
+	typeChecker.constants[reference] = constDef
+
+	if constDef.WhereExp != nil {
+		var whereType = typeChecker.acceptSubType(constDef.WhereExp)
+
+		if whereType.GetNodeType() != parser.BooleanNodeType && !parser.IsUndefined(whereType) {
+			typeChecker.reportError(constDef.WhereExp.Begin(), "The where expression of a constant must evaluate to a boolean")
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package typechecker
+
+import (
+	"fmt"
+	"strings"
+	"zen/parser"
+)
+
+type typeSubstitutions map[*parser.TypeParameterType]parser.TypeNode
+
+func copyStructure(structure *parser.StructureTypeType, entryTypes []parser.TypeNode) *parser.StructureTypeType {
+	var entries []*parser.StructureNamedEntryType = nil
+
+	for index, entry := range structure.Entries {
+		var copied = parser.NewStructureNamedEntryType(entry.Name, entryTypes[index], entry.IsMutable)
+		copied.IsPublic = entry.IsPublic
+		copied.Module = entry.Module
+		entries = append(entries, copied)
+	}
+
+	var result = parser.NewStructureTypeType(entries)
+	result.SetWhereExpression(structure.GetWhereExpression())
+	return result
+}
+
+func copyArray(array *parser.ArrayTypeType, elementType parser.TypeNode) *parser.ArrayTypeType {
+	var result = parser.NewArrayTypeType(elementType)
+	result.SetWhereExpression(array.GetWhereExpression())
+	return result
+}
+
+func copyFunction(function *parser.FunctionTypeType, input *parser.StructureTypeType, output *parser.StructureTypeType) *parser.FunctionTypeType {
+	var result = parser.NewFunctionTypeType(input, output)
+	result.SetWhereExpression(function.GetWhereExpression())
+	return result
+}
+
+func substituteEntries(structure *parser.StructureTypeType, substitutions typeSubstitutions) ([]parser.TypeNode, bool) {
+	var result []parser.TypeNode = nil
+	var changed = false
+
+	for _, entry := range structure.Entries {
+		var entryType = substituteType(entry.Type, substitutions)
+		changed = changed || entryType != entry.Type
+		result = append(result, entryType)
+	}
+
+	return result, changed
+}
+
+// Replaces type parameters with the types they stand for. Types that don't
+// mention a replaced parameter are left as they are
+func substituteType(typeNode parser.TypeNode, substitutions typeSubstitutions) parser.TypeNode {
+	asParameter, ok := typeNode.(*parser.TypeParameterType)
+
+	if ok {
+		replacement, ok := substitutions[asParameter]
+
+		if ok {
+			return replacement
+		}
+
+		return typeNode
+	}
+
+	asStructure, ok := typeNode.(*parser.StructureTypeType)
+
+	if ok {
+		entryTypes, changed := substituteEntries(asStructure, substitutions)
+
+		if changed {
+			return copyStructure(asStructure, entryTypes)
+		}
+
+		return typeNode
+	}
+
+	asArray, ok := typeNode.(*parser.ArrayTypeType)
+
+	if ok {
+		var elementType = substituteType(asArray.ElementType, substitutions)
+
+		if elementType != asArray.ElementType {
+			return copyArray(asArray, elementType)
+		}
+
+		return typeNode
+	}
+
+	asFunction, ok := typeNode.(*parser.FunctionTypeType)
+
+	if ok {
+		var input = substituteType(asFunction.Input, substitutions).(*parser.StructureTypeType)
+		var output = substituteType(asFunction.Output, substitutions).(*parser.StructureTypeType)
+
+		if input != asFunction.Input || output != asFunction.Output {
+			return copyFunction(asFunction, input, output)
+		}
+
+		return typeNode
+	}
+
+	return typeNode
+}
+
+// Every instance of a generic type is a new type so the where expression of
+// each instance is checked against its own values
+func instantiateType(typeNode parser.TypeNode, substitutions typeSubstitutions) parser.TypeNode {
+	var result = substituteType(typeNode, substitutions)
+
+	if result != typeNode {
+		return result
+	}
+
+	asStructure, ok := typeNode.(*parser.StructureTypeType)
+
+	if ok {
+		var entryTypes []parser.TypeNode = nil
+
+		for _, entry := range asStructure.Entries {
+			entryTypes = append(entryTypes, entry.Type)
+		}
+
+		return copyStructure(asStructure, entryTypes)
+	}
+
+	asArray, ok := typeNode.(*parser.ArrayTypeType)
+
+	if ok {
+		return copyArray(asArray, asArray.ElementType)
+	}
+
+	asFunction, ok := typeNode.(*parser.FunctionTypeType)
+
+	if ok {
+		return copyFunction(asFunction, asFunction.Input, asFunction.Output)
+	}
+
+	return typeNode
+}
+
+// Finds the types the parameters of a generic signature stand for by matching
+// the shape of the signature against the types given to it
+func unifyTypes(parameterType parser.TypeNode, argumentType parser.TypeNode, bindings typeSubstitutions) {
+	asParameter, ok := parameterType.(*parser.TypeParameterType)
+
+	if ok {
+		existing, isBindable := bindings[asParameter]
+
+		if isBindable && existing == nil && !parser.IsUndefined(argumentType) {
+			bindings[asParameter] = argumentType
+		}
+
+		return
+	}
+
+	asStructure, ok := parameterType.(*parser.StructureTypeType)
+	argumentStructure, argumentOk := argumentType.(*parser.StructureTypeType)
+
+	if ok && argumentOk && len(asStructure.Entries) == len(argumentStructure.Entries) {
+		for index, entry := range asStructure.Entries {
+			unifyTypes(entry.Type, argumentStructure.Entries[index].Type, bindings)
+		}
+
+		return
+	}
+
+	asArray, ok := parameterType.(*parser.ArrayTypeType)
+	argumentArray, argumentOk := argumentType.(*parser.ArrayTypeType)
+
+	if ok && argumentOk {
+		unifyTypes(asArray.ElementType, argumentArray.ElementType, bindings)
+		return
+	}
+
+	asFunction, ok := parameterType.(*parser.FunctionTypeType)
+	argumentFunction, argumentOk := argumentType.(*parser.FunctionTypeType)
+
+	if ok && argumentOk {
+		unifyTypes(asFunction.Input, argumentFunction.Input, bindings)
+		unifyTypes(asFunction.Output, argumentFunction.Output, bindings)
+	}
+}
+
+func instanceKey(generic parser.TypeNode, parameters []*parser.TypeParameterType, substitutions typeSubstitutions) string {
+	var builder strings.Builder
+
+	builder.WriteString(fmt.Sprintf("%d", generic.UniqueId()))
+
+	for _, parameter := range parameters {
+		builder.WriteString(fmt.Sprintf(":%d", substitutions[parameter].UniqueId()))
+	}
+
+	return builder.String()
+}
+
+func (typeChecker *TypeChecker) getInstance(generic parser.TypeNode, parameters []*parser.TypeParameterType, substitutions typeSubstitutions) parser.TypeNode {
+	var key = instanceKey(generic, parameters, substitutions)
+	result, ok := typeChecker.instances[key]
+
+	if !ok {
+		result = instantiateType(generic, substitutions)
+		typeChecker.instances[key] = result
+	}
+
+	return result
+}
+
+func (typeChecker *TypeChecker) declareTypeParameters(typeParameters []*parser.TypeParameter) []*parser.TypeParameterType {
+	var topScope = typeChecker.peekScope()
+	var result []*parser.TypeParameterType = nil
+
+	for _, typeParameter := range typeParameters {
+		if typeParameter.Type == nil {
+			typeParameter.Type = parser.NewTypeParameterType(typeParameter.Name.Value)
+		}
+
+		_, alreadyDefined := topScope.typeMap[typeParameter.Name.Value]
+
+		if alreadyDefined {
+			typeChecker.reportError(typeParameter.Name.At, "Type parameter '"+typeParameter.Name.Value+"' is already defined")
+		}
+
+		topScope.typeMap[typeParameter.Name.Value] = &TypeReference{typeParameter.Type, nil}
+		result = append(result, typeParameter.Type)
+	}
+
+	return result
+}
+
+func (typeChecker *TypeChecker) instantiateNamedType(namedType *parser.NamedType, typeReference *TypeReference) parser.TypeNode {
+	var substitutions = make(typeSubstitutions)
+	var isUndefined = false
+
+	for index, typeArgument := range namedType.TypeArguments {
+		var argumentType = typeChecker.acceptSubType(typeArgument)
+		isUndefined = isUndefined || parser.IsUndefined(argumentType)
+
+		if index < len(typeReference.Parameters) {
+			substitutions[typeReference.Parameters[index]] = argumentType
+		}
+	}
+
+	if len(namedType.TypeArguments) != len(typeReference.Parameters) {
+		typeChecker.reportError(namedType.Begin(), fmt.Sprintf(
+			"Type %s expects %d type arguments got %d",
+			namedType.Token.Value,
+			len(typeReference.Parameters),
+			len(namedType.TypeArguments),
+		))
+		return &parser.UndefinedType{}
+	} else if isUndefined {
+		return &parser.UndefinedType{}
+	}
+
+	return typeChecker.getInstance(typeReference.Type, typeReference.Parameters, substitutions)
+}
+
+func (typeChecker *TypeChecker) inferTypeArguments(generic *parser.GenericFunctionType, exp *parser.CallExpression, argumentTypes []parser.TypeNode) parser.TypeNode {
+	var bindings = make(typeSubstitutions)
+
+	for _, parameter := range generic.Parameters {
+		bindings[parameter] = nil
+	}
+
+	for index, input := range generic.Function.Input.Entries {
+		if index < len(argumentTypes) {
+			unifyTypes(input.Type, argumentTypes[index], bindings)
+		}
+	}
+
+	for _, argumentType := range argumentTypes {
+		if parser.IsUndefined(argumentType) {
+			return &parser.UndefinedType{}
+		}
+	}
+
+	for _, parameter := range generic.Parameters {
+		if bindings[parameter] == nil {
+			typeChecker.reportError(exp.Begin(), "Could not infer type parameter '"+parameter.Name+"'")
+			return &parser.UndefinedType{}
+		}
+	}
+
+	return typeChecker.getInstance(generic.Function, generic.Parameters, bindings)
+}
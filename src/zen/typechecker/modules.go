@@ -0,0 +1,124 @@
+package typechecker
+
+import (
+	"sort"
+	"zen/loader"
+	"zen/parser"
+)
+
+// The public types, functions and constants of a module. Public unions also export
+// the constructors of their variants
+func exportModule(symbols *scopeTypeReferences, moduleScope *VariableScope) *VariableScope {
+	var result = &VariableScope{
+		nil,
+		make(map[string]*VariableReference),
+		make(map[string]*TypeReference),
+		false,
+	}
+
+	for name, typeSymbol := range symbols.typeSymbols {
+		asTypeDef, ok := typeSymbol.(*parser.TypeDefinition)
+
+		if !ok || !asTypeDef.IsPublic || moduleScope.typeMap[name] == nil {
+			continue
+		}
+
+		result.typeMap[name] = moduleScope.typeMap[name]
+
+		if asUnion, ok := asTypeDef.Type.(*parser.UnionTypeType); ok {
+			for _, variant := range asUnion.Variants {
+				result.variableMap[variant.Name] = moduleScope.variableMap[variant.Name]
+			}
+		}
+	}
+
+	for name, symbol := range symbols.symbols {
+		asFnDef, isFunction := symbol.(*parser.FunctionDefinition)
+		asConstDef, isConstant := symbol.(*parser.ConstantDefinition)
+		var isPublic = (isFunction && asFnDef.IsPublic) || (isConstant && asConstDef.IsPublic)
+
+		if isPublic && moduleScope.variableMap[name] != nil {
+			result.variableMap[name] = moduleScope.variableMap[name]
+		}
+	}
+
+	return result
+}
+
+func sortedKeys(names map[string]bool) []string {
+	var result []string = nil
+
+	for name := range names {
+		result = append(result, name)
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+// Imports apply to every file of a module. The same name can't come from
+// two different modules
+func (typeChecker *TypeChecker) importModules(module *loader.Module, scope *VariableScope) {
+	var importedFrom = make(map[string]string)
+
+	for _, fileDef := range module.Files {
+		for _, importPath := range fileDef.Imports {
+			var exports = typeChecker.exports[importPath.Name()]
+
+			if exports == nil {
+				continue
+			}
+
+			var names = make(map[string]bool)
+
+			for name, typeReference := range exports.typeMap {
+				scope.typeMap[name] = typeReference
+				names[name] = true
+			}
+
+			for name, variableReference := range exports.variableMap {
+				scope.variableMap[name] = variableReference
+				names[name] = true
+			}
+
+			for _, name := range sortedKeys(names) {
+				from, ok := importedFrom[name]
+
+				if ok && from != importPath.Name() {
+					typeChecker.reportError(importPath.Begin(), "'"+name+"' is imported from both '"+from+"' and '"+importPath.Name()+"'")
+				}
+
+				importedFrom[name] = importPath.Name()
+			}
+		}
+	}
+}
+
+// Checks modules in the order given so every module has to come after the
+// modules it imports. Modules only see the public definitions of imports
+func CheckModules(modules []*loader.Module) []parser.ParseError {
+	var checker = CreateTypeChecker()
+	checker.createScope().initializeDefaultTypes()
+
+	for _, module := range modules {
+		symbols, errors := collectModuleSymbols(module.Files)
+		checker.errors = append(checker.errors, errors...)
+
+		checker.importModules(module, checker.createScope())
+		var moduleScope = checker.createScope()
+		checker.module = module.Name
+		checker.checkDefinitions(module.Files)
+
+		if module.Name != "" {
+			checker.exports[module.Name] = exportModule(symbols, moduleScope)
+		}
+
+		checker.popScope()
+		checker.popScope()
+	}
+
+	checker.module = ""
+	checker.popScope()
+	return checker.errors
+}
@@ -0,0 +1,89 @@
+package typechecker
+
+import (
+	"testing"
+	"zen/loader"
+	"zen/parser"
+	"zen/source"
+	"zen/test"
+)
+
+func parseModule(t *testing.T, name string, sources ...string) *loader.Module {
+	var result = &loader.Module{Name: name}
+
+	for _, sourceString := range sources {
+		var file, errors = parser.Parse(source.SourceFromString(sourceString))
+
+		if len(errors) > 0 {
+			for _, err := range errors {
+				t.Log(parser.FormatError(err))
+			}
+			t.Fatalf("Error parsing source")
+		}
+
+		result.Files = append(result.Files, file)
+	}
+
+	return result
+}
+
+func TestModules(t *testing.T) {
+	var geometry = parseModule(t, "geometry", `
+		module geometry
+
+		pub type Point [pub mut x: i32, mut y: i32]
+
+		pub func Origin[] => [p: Point] {
+			return [0, 0]
+		}
+
+		func hidden[] => [r: i32] {
+			return 1
+		}
+	`, `
+		module geometry
+
+		pub func MoveUp[p: Point] => [] {
+			p.y = p.y + hidden()
+			return
+		}
+	`)
+
+	var errors = CheckModules([]*loader.Module{geometry, parseModule(t, "", `
+		import geometry
+
+		func Test[] => [r: i32] {
+			let p = Origin()
+			p.x = 1
+			MoveUp(p)
+			return p.y
+		}
+	`)})
+	test.Assert(t, len(errors) == 0, "Public definitions should be usable from every file of a module and from imports")
+
+	errors = CheckModules([]*loader.Module{geometry, parseModule(t, "", `
+		import geometry
+
+		func Test[] => [r: i32] {
+			let p = Origin()
+			p.y = 1
+			return hidden()
+		}
+	`)})
+	test.Assert(t, len(errors) == 2, "Private fields should not be modified and private functions not be used outside of their module")
+
+	errors = CheckModules([]*loader.Module{parseModule(t, "geometry", `
+		module geometry
+
+		func Test[] => [] {
+			return
+		}
+	`, `
+		module geometry
+
+		func Test[] => [] {
+			return
+		}
+	`)})
+	test.Assert(t, len(errors) == 1, "A name should only be defined by one file of a module")
+}
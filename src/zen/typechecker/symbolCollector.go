@@ -1,6 +1,9 @@
 package typechecker
 
-import "zen/parser"
+import (
+	"sort"
+	"zen/parser"
+)
 
 type scopeTypeReferences struct {
 	typeSymbols map[string]parser.TypeSymbolDefinition
@@ -82,12 +85,60 @@ func (symbolCollector *symbolCollector) VisitStructureExpression(exp *parser.Str
 	}
 }
 
+func (symbolCollector *symbolCollector) VisitCallExpression(exp *parser.CallExpression) {
+	exp.Function.Accept(symbolCollector)
+
+	for _, argument := range exp.Arguments {
+		argument.Accept(symbolCollector)
+	}
+}
+
+func (symbolCollector *symbolCollector) VisitIndexExpression(exp *parser.IndexExpression) {
+	exp.Left.Accept(symbolCollector)
+	exp.Index.Accept(symbolCollector)
+}
+
+func (symbolCollector *symbolCollector) VisitMatchExpression(match *parser.MatchExpression) {
+	match.Subject.Accept(symbolCollector)
+
+	for _, arm := range match.Arms {
+		arm.Value.Accept(symbolCollector)
+	}
+}
+
 func (symbolCollector *symbolCollector) VisitIf(ifStatement *parser.IfStatement) {
 	ifStatement.Expresssion.Accept(symbolCollector)
 	ifStatement.Body.Accept(symbolCollector)
 	ifStatement.ElseBody.Accept(symbolCollector)
 }
 
+func (symbolCollector *symbolCollector) VisitWhile(whileStatement *parser.WhileStatement) {
+	whileStatement.Condition.Accept(symbolCollector)
+
+	if whileStatement.Invariant != nil {
+		whileStatement.Invariant.Accept(symbolCollector)
+	}
+
+	if whileStatement.Decreases != nil {
+		whileStatement.Decreases.Accept(symbolCollector)
+	}
+
+	whileStatement.Body.Accept(symbolCollector)
+}
+
+func (symbolCollector *symbolCollector) VisitForeach(foreach *parser.ForeachStatement) {
+	foreach.Collection.Accept(symbolCollector)
+	foreach.Body.Accept(symbolCollector)
+}
+
+func (symbolCollector *symbolCollector) VisitMatch(match *parser.MatchStatement) {
+	match.Subject.Accept(symbolCollector)
+
+	for _, arm := range match.Arms {
+		arm.Body.Accept(symbolCollector)
+	}
+}
+
 func (symbolCollector *symbolCollector) VisitBody(body *parser.Body) {
 	startScope(symbolCollector, body.Scope)
 
@@ -104,6 +155,35 @@ func (symbolCollector *symbolCollector) VisitReturn(ret *parser.ReturnStatement)
 	}
 }
 
+func (symbolCollector *symbolCollector) VisitVarDef(varDef *parser.VariableDefinition) {
+	symbolCollector.currentReferences.symbols[varDef.Name.Value] = varDef
+
+	if varDef.TypeExp != nil {
+		varDef.TypeExp.Accept(symbolCollector)
+	}
+
+	if varDef.Value != nil {
+		varDef.Value.Accept(symbolCollector)
+	}
+}
+
+func (symbolCollector *symbolCollector) VisitDestructure(destructure *parser.DestructuringDefinition) {
+	destructure.Value.Accept(symbolCollector)
+}
+
+func (symbolCollector *symbolCollector) VisitAssignment(assignment *parser.AssignmentStatement) {
+	assignment.Target.Accept(symbolCollector)
+	assignment.Value.Accept(symbolCollector)
+}
+
+func (symbolCollector *symbolCollector) VisitAssert(assert *parser.AssertStatement) {
+	assert.Expression.Accept(symbolCollector)
+}
+
+func (symbolCollector *symbolCollector) VisitUnreachable(unreachable *parser.UnreachableStatement) {
+
+}
+
 func (symbolCollector *symbolCollector) VisitNamedType(namedType *parser.NamedType) {
 
 }
@@ -126,23 +206,49 @@ func (symbolCollector *symbolCollector) VisitFunctionType(fn *parser.FunctionTyp
 	fn.Output.Accept(symbolCollector)
 }
 
+func (symbolCollector *symbolCollector) VisitArrayType(array *parser.ArrayType) {
+	array.ElementType.Accept(symbolCollector)
+}
+
 func (symbolCollector *symbolCollector) VisitWhereType(where *parser.WhereType) {
 	where.TypeExp.Accept(symbolCollector)
 	where.WhereExp.Accept(symbolCollector)
 }
 
+func (symbolCollector *symbolCollector) VisitUnionType(union *parser.UnionType) {
+	for _, variant := range union.Variants {
+		if variant.TypeExp != nil {
+			variant.TypeExp.Accept(symbolCollector)
+		}
+	}
+}
+
 func (symbolCollector *symbolCollector) VisitTypeDef(typeDef *parser.TypeDefinition) {
-	startScope(symbolCollector, typeDef.Scope)
 	symbolCollector.currentReferences.typeSymbols[typeDef.Name.Value] = typeDef
+	startScope(symbolCollector, typeDef.Scope)
 	typeDef.TypeExp.Accept(symbolCollector)
 	endScope(symbolCollector)
 }
 
 func (symbolCollector *symbolCollector) VisitFnDef(fnDef *parser.FunctionDefinition) {
-	symbolCollector.currentReferences.symbols[fnDef.Name.Value] = fnDef.Function
+	symbolCollector.currentReferences.symbols[fnDef.Name.Value] = fnDef
 	fnDef.Function.Accept(symbolCollector)
 }
 
+func (symbolCollector *symbolCollector) VisitConst(constDef *parser.ConstantDefinition) {
+	symbolCollector.currentReferences.symbols[constDef.Name.Value] = constDef
+
+	if constDef.TypeExp != nil {
+		constDef.TypeExp.Accept(symbolCollector)
+	}
+
+	constDef.Value.Accept(symbolCollector)
+
+	if constDef.WhereExp != nil {
+		constDef.WhereExp.Accept(symbolCollector)
+	}
+}
+
 func (symbolCollector *symbolCollector) VisitFile(fileDef *parser.FileDefinition) {
 	startScope(symbolCollector, fileDef.Scope)
 	for _, definition := range fileDef.Definitions {
@@ -150,3 +256,54 @@ func (symbolCollector *symbolCollector) VisitFile(fileDef *parser.FileDefinition
 	}
 	endScope(symbolCollector)
 }
+
+func sortedNames(definitions map[string]parser.ParseNode) []string {
+	var result []string = nil
+
+	for name := range definitions {
+		result = append(result, name)
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+// The top level definitions of every file in a module. A name can only be
+// defined by one of the files
+func collectModuleSymbols(files []*parser.FileDefinition) (*scopeTypeReferences, []parser.ParseError) {
+	var result = createScopeTypeReferences()
+	var errors []parser.ParseError = nil
+
+	for _, fileDef := range files {
+		var fileSymbols = CollectSymbols(fileDef).symbols[fileDef.Scope.Id]
+		var definitions = make(map[string]parser.ParseNode)
+
+		for name, typeSymbol := range fileSymbols.typeSymbols {
+			definitions[name] = typeSymbol
+		}
+
+		for name, symbol := range fileSymbols.symbols {
+			definitions[name] = symbol
+		}
+
+		for _, name := range sortedNames(definitions) {
+			_, isType := result.typeSymbols[name]
+			_, isSymbol := result.symbols[name]
+
+			if isType || isSymbol {
+				errors = append(errors, parser.CreateError(definitions[name].Begin(), "'"+name+"' is already defined in another file of the module"))
+			}
+		}
+
+		for name, typeSymbol := range fileSymbols.typeSymbols {
+			result.typeSymbols[name] = typeSymbol
+		}
+
+		for name, symbol := range fileSymbols.symbols {
+			result.symbols[name] = symbol
+		}
+	}
+
+	return result, errors
+}
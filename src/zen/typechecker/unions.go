@@ -0,0 +1,154 @@
+package typechecker
+
+import (
+	"strings"
+	"zen/parser"
+	"zen/tokenizer"
+)
+
+func (typeChecker *TypeChecker) VisitUnionType(union *parser.UnionType) {
+	var variants []*parser.UnionVariantType = nil
+	var defined = make(map[string]bool)
+
+	for _, variant := range union.Variants {
+		var structure = parser.NewStructureTypeType(nil)
+
+		if variant.TypeExp != nil {
+			var variantType = typeChecker.acceptSubType(variant.TypeExp)
+			asStructure, ok := variantType.(*parser.StructureTypeType)
+
+			if ok {
+				structure = asStructure
+			} else if !parser.IsUndefined(variantType) {
+				typeChecker.reportError(variant.TypeExp.Begin(), "Variant '"+variant.Name.Value+"' must be a structure")
+			}
+		}
+
+		if defined[variant.Name.Value] {
+			typeChecker.reportError(variant.Name.At, "Variant '"+variant.Name.Value+"' is already defined")
+			continue
+		}
+
+		defined[variant.Name.Value] = true
+		variants = append(variants, &parser.UnionVariantType{Name: variant.Name.Value, Type: structure})
+	}
+
+	union.Type = parser.NewUnionTypeType(variants)
+	typeChecker.pushType(union.Type)
+}
+
+// Values of a variant are made by calling its name with its fields. The
+// where expression of the variant becomes the precondition of the call
+func (typeChecker *TypeChecker) declareVariantConstructors(union *parser.UnionTypeType, typeParameters []*parser.TypeParameterType) {
+	var topScope = typeChecker.peekScope()
+
+	for _, variant := range union.Variants {
+		var inputs []*parser.StructureNamedEntryType = nil
+
+		for _, entry := range variant.Type.Entries {
+			inputs = append(inputs, parser.NewStructureNamedEntryType(entry.Name, entry.Type, false))
+		}
+
+		var output = parser.NewStructureTypeType([]*parser.StructureNamedEntryType{
+			parser.NewStructureNamedEntryType("result", union, false),
+		})
+
+		var constructor = parser.NewFunctionTypeType(parser.NewStructureTypeType(inputs), output)
+		constructor.SetWhereExpression(variant.Type.GetWhereExpression())
+		topScope.variableMap[variant.Name] = &VariableReference{functionValueType(constructor, typeParameters), false}
+	}
+}
+
+// Every variant of the union needs an arm. A matched variable has the type
+// of the variant inside of its arm so its fields can be used there. Returns
+// the types of the values of the arms
+func (typeChecker *TypeChecker) checkMatchArms(subject parser.Expression, arms []*parser.MatchArm, at tokenizer.SourceLocation) []parser.TypeNode {
+	var subjectType = typeChecker.acceptSubType(subject)
+	asUnion, ok := subjectType.(*parser.UnionTypeType)
+
+	if !ok && !parser.IsUndefined(subjectType) {
+		typeChecker.reportError(subject.Begin(), "Only unions can be matched")
+	}
+
+	asIdentifier, isIdentifier := subject.(*parser.Identifier)
+	var matched = make(map[string]bool)
+	var valueTypes []parser.TypeNode = nil
+
+	for _, arm := range arms {
+		var scope = typeChecker.createScope()
+
+		if ok {
+			var variant = asUnion.GetVariant(arm.Variant.Value)
+
+			if variant == nil {
+				typeChecker.reportError(arm.Variant.At, "Variant '"+arm.Variant.Value+"' does not exist on type")
+			} else if matched[variant.Name] {
+				typeChecker.reportError(arm.Variant.At, "Variant '"+variant.Name+"' is matched more than once")
+			} else {
+				matched[variant.Name] = true
+				arm.Type = variant.Type
+
+				if isIdentifier {
+					scope.variableMap[asIdentifier.Token.Value] = &VariableReference{variant.Type, false}
+				}
+			}
+		}
+
+		if arm.Body != nil {
+			typeChecker.acceptSubType(arm.Body)
+		} else {
+			valueTypes = append(valueTypes, typeChecker.acceptSubType(arm.Value))
+		}
+
+		typeChecker.popScope()
+	}
+
+	if !ok {
+		return valueTypes
+	}
+
+	var missing []string = nil
+
+	for _, variant := range asUnion.Variants {
+		if !matched[variant.Name] {
+			missing = append(missing, "'"+variant.Name+"'")
+		}
+	}
+
+	if len(missing) > 0 {
+		typeChecker.reportError(at, "Match does not handle "+strings.Join(missing, ", "))
+	}
+
+	return valueTypes
+}
+
+func (typeChecker *TypeChecker) VisitMatch(match *parser.MatchStatement) {
+	typeChecker.checkMatchArms(match.Subject, match.Arms, match.Begin())
+}
+
+// The value of a match has the type of its first arm. Integer arms can mix
+// types like the operands of arithmetic do
+func (typeChecker *TypeChecker) VisitMatchExpression(match *parser.MatchExpression) {
+	var valueTypes = typeChecker.checkMatchArms(match.Subject, match.Arms, match.Begin())
+	var result parser.TypeNode = &parser.UndefinedType{}
+
+	for index, valueType := range valueTypes {
+		asInteger, isInteger := valueType.(*parser.IntegerType)
+		resultInteger, resultIsInteger := result.(*parser.IntegerType)
+
+		if parser.IsUndefined(valueType) {
+			continue
+		} else if valueType.GetNodeType() == parser.VoidNodeType {
+			typeChecker.reportError(match.Arms[index].Value.Begin(), "Match arms must have a value")
+		} else if parser.IsUndefined(result) {
+			result = valueType
+		} else if isInteger && resultIsInteger {
+			result = arithmeticResultType(resultInteger, asInteger)
+		} else if !canAssign(result, valueType) {
+			typeChecker.reportError(match.Arms[index].Value.Begin(), "Match arms must have compatible types")
+		}
+	}
+
+	match.Type = result
+	typeChecker.pushType(result)
+}
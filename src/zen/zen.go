@@ -1,39 +1,251 @@
 package main
 
 import (
-	"log"
+	"flag"
+	"fmt"
+	"os"
 	"zen/constraintchecker"
+	"zen/loader"
 	"zen/parser"
 	"zen/source"
+	"zen/tokenizer"
 	"zen/typechecker"
 )
 
+const (
+	exitSuccess    = 0
+	exitCheckFail  = 1
+	exitUsageError = 2
+)
+
+const (
+	stageParse       = "parse"
+	stageTypes       = "types"
+	stageConstraints = "constraints"
+)
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "usage: zen <command> [flags] <files...>")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "commands:")
+	fmt.Fprintln(os.Stderr, "  check   parse, type check and verify the constraints of each file")
+	fmt.Fprintln(os.Stderr, "  parse   parse each file and report syntax errors")
+	fmt.Fprintln(os.Stderr, "  tokens  print the tokens of each file")
+}
+
+// Prints every error and warning. Returns false if there were errors
 func checkErrors(errors []parser.ParseError) bool {
-	if len(errors) == 0 {
-		return true
-	} else {
-		for _, element := range errors {
-			log.Println(parser.FormatError(element))
+	var result = true
+
+	for _, element := range errors {
+		fmt.Fprintln(os.Stderr, parser.FormatError(element))
+
+		if !element.IsWarning() {
+			result = false
+		}
+	}
+
+	return result
+}
+
+func loadSources(filenames []string) ([]*source.Source, bool) {
+	var result []*source.Source = nil
+
+	for _, filename := range filenames {
+		src, err := source.SourceFromFile(filename)
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading source %s\n", err)
+			return nil, false
 		}
 
-		return false
+		result = append(result, src)
 	}
+
+	return result, true
 }
 
-func main() {
-	source, err := source.SourceFromFile("../../test/Min.zen")
+type checkSummary struct {
+	fileCount int
+	failed    map[*source.Source]bool
+	warnings  int
+}
+
+// Errors are counted by the file they are in, warnings are only counted
+func (summary *checkSummary) markFailed(errors []parser.ParseError) bool {
+	for _, element := range errors {
+		if element.IsWarning() {
+			summary.warnings = summary.warnings + 1
+		} else {
+			summary.failed[element.At.Source] = true
+		}
+	}
+
+	return checkErrors(errors)
+}
+
+func (summary *checkSummary) String() string {
+	var result = fmt.Sprintf("%d files checked", summary.fileCount)
+
+	if len(summary.failed) != 0 {
+		result = fmt.Sprintf("%d of %d files failed", len(summary.failed), summary.fileCount)
+	}
+
+	if summary.warnings == 1 {
+		result = result + ", 1 warning"
+	} else if summary.warnings > 1 {
+		result = result + fmt.Sprintf(", %d warnings", summary.warnings)
+	}
+
+	return result
+}
+
+// Checks the given files together with every module they import
+func checkModules(root string, sources []*source.Source, stopAfter string) *checkSummary {
+	var modules, errors = loader.Load(root, sources)
+	var summary = &checkSummary{0, make(map[*source.Source]bool), 0}
+
+	for _, module := range modules {
+		summary.fileCount = summary.fileCount + len(module.Files)
+	}
+
+	if !summary.markFailed(errors) || stopAfter == stageParse {
+		return summary
+	}
+
+	if !summary.markFailed(typechecker.CheckModules(modules)) || stopAfter == stageTypes {
+		return summary
+	}
+
+	for _, module := range modules {
+		for _, fileDef := range module.Files {
+			summary.markFailed(constraintchecker.CheckConstraints(fileDef))
+		}
+	}
+
+	return summary
+}
+
+func runCheck(args []string) int {
+	var flags = flag.NewFlagSet("check", flag.ContinueOnError)
+	var stopAfter = flags.String("stop-after", stageConstraints, "stop after the given stage: parse, types or constraints")
+	var root = flags.String("root", ".", "the directory imported modules are loaded from")
 
-	if err != nil {
-		log.Fatalf("Error loading source %s", err)
+	if flags.Parse(args) != nil {
+		return exitUsageError
+	}
+
+	if *stopAfter != stageParse && *stopAfter != stageTypes && *stopAfter != stageConstraints {
+		fmt.Fprintf(os.Stderr, "Unknown stage '%s'\n", *stopAfter)
+		return exitUsageError
+	}
+
+	sources, ok := loadSources(flags.Args())
+
+	if !ok {
+		return exitUsageError
+	} else if len(sources) == 0 {
+		fmt.Fprintln(os.Stderr, "No input files")
+		return exitUsageError
+	}
+
+	var summary = checkModules(*root, sources, *stopAfter)
+
+	if len(summary.failed) != 0 {
+		fmt.Fprintln(os.Stderr, summary.String())
+		return exitCheckFail
+	} else if summary.warnings != 0 {
+		fmt.Fprintln(os.Stderr, summary.String())
+	}
+
+	return exitSuccess
+}
+
+func runParse(args []string) int {
+	var flags = flag.NewFlagSet("parse", flag.ContinueOnError)
+	var dumpAst = flags.Bool("dump-ast", false, "print the parse tree of each file")
+
+	if flags.Parse(args) != nil {
+		return exitUsageError
+	}
+
+	sources, ok := loadSources(flags.Args())
+
+	if !ok {
+		return exitUsageError
+	} else if len(sources) == 0 {
+		fmt.Fprintln(os.Stderr, "No input files")
+		return exitUsageError
+	}
+
+	var result = exitSuccess
+
+	for _, src := range sources {
+		var fileDef, errors = parser.Parse(src)
+
+		if !checkErrors(errors) {
+			result = exitCheckFail
+		}
+
+		if *dumpAst && fileDef != nil {
+			fmt.Print(parser.PrintTree(fileDef))
+		}
+	}
+
+	return result
+}
+
+func runTokens(args []string) int {
+	var flags = flag.NewFlagSet("tokens", flag.ContinueOnError)
+
+	if flags.Parse(args) != nil {
+		return exitUsageError
+	}
+
+	sources, ok := loadSources(flags.Args())
+
+	if !ok {
+		return exitUsageError
+	} else if len(sources) == 0 {
+		fmt.Fprintln(os.Stderr, "No input files")
+		return exitUsageError
+	}
+
+	var result = exitSuccess
+
+	for _, src := range sources {
+		var tokens = tokenizer.Tokenize(src)
+
+		for _, token := range tokens.Tokens {
+			fmt.Printf("%d\t%s\t%q\n", token.At.At, token.TokenType, token.Value)
+
+			if token.TokenType == tokenizer.ErrorToken {
+				result = exitCheckFail
+			}
+		}
+	}
+
+	return result
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(exitUsageError)
 	}
 
-	var parseResult, errors = parser.Parse(source)
+	var args = os.Args[2:]
 
-	if checkErrors(errors) &&
-		checkErrors(typechecker.CheckTypes(parseResult)) &&
-		checkErrors(constraintchecker.CheckConstraints(parseResult)) {
-		log.Print("Success")
-	} else {
-		log.Print("Fail")
+	switch os.Args[1] {
+	case "check":
+		os.Exit(runCheck(args))
+	case "parse":
+		os.Exit(runParse(args))
+	case "tokens":
+		os.Exit(runTokens(args))
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command '%s'\n", os.Args[1])
+		printUsage()
+		os.Exit(exitUsageError)
 	}
 }
@@ -0,0 +1,158 @@
+package zmath
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// A matrix of exact rationals. Row operations on 64 bit values can grow past
+// 64 bits so this is used where the entries come from user constants
+type MatrixRat struct {
+	data [][]*big.Rat
+	Rows uint32
+	Cols uint32
+}
+
+func NewMatrixRat(Rows uint32, Cols uint32) *MatrixRat {
+	var result = &MatrixRat{
+		make([][]*big.Rat, Rows),
+		Rows,
+		Cols,
+	}
+
+	for row := uint32(0); row < Rows; row = row + 1 {
+		result.data[row] = make([]*big.Rat, Cols)
+
+		for col := uint32(0); col < Cols; col = col + 1 {
+			result.data[row][col] = new(big.Rat)
+		}
+	}
+
+	return result
+}
+
+func (matrix *MatrixRat) InitializeIdentity() {
+	for row := uint32(0); row < matrix.Rows; row = row + 1 {
+		for col := uint32(0); col < matrix.Cols; col = col + 1 {
+			if row == col {
+				matrix.data[row][col].SetInt64(1)
+			} else {
+				matrix.data[row][col].SetInt64(0)
+			}
+		}
+	}
+}
+
+// New rows and columns are filled in from the identity matrix
+func (matrix *MatrixRat) Resize(Rows uint32, Cols uint32) {
+	var data = make([][]*big.Rat, Rows)
+
+	for row := uint32(0); row < Rows; row = row + 1 {
+		data[row] = make([]*big.Rat, Cols)
+
+		for col := uint32(0); col < Cols; col = col + 1 {
+			if row < matrix.Rows && col < matrix.Cols {
+				data[row][col] = matrix.data[row][col]
+			} else if row == col {
+				data[row][col] = big.NewRat(1, 1)
+			} else {
+				data[row][col] = new(big.Rat)
+			}
+		}
+	}
+
+	matrix.data = data
+	matrix.Rows = Rows
+	matrix.Cols = Cols
+}
+
+func (matrix *MatrixRat) GetEntry(row uint32, col uint32) *big.Rat {
+	return matrix.data[row][col]
+}
+
+func (matrix *MatrixRat) SetEntry(row uint32, col uint32, value *big.Rat) {
+	matrix.data[row][col].Set(value)
+}
+
+func (a *MatrixRat) Mul(b *MatrixRat) (result *MatrixRat, err error) {
+	if a.Cols != b.Rows {
+		return nil, errors.New("Matrix sizes are not compatible for multiplication")
+	}
+
+	result = NewMatrixRat(a.Rows, b.Cols)
+	var product = new(big.Rat)
+
+	for row := uint32(0); row < result.Rows; row = row + 1 {
+		for col := uint32(0); col < result.Cols; col = col + 1 {
+			var rowValue = result.data[row][col]
+
+			for span := uint32(0); span < a.Cols; span = span + 1 {
+				rowValue.Add(rowValue, product.Mul(a.data[row][span], b.data[span][col]))
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (matrix *MatrixRat) Scale(value *big.Rat) *MatrixRat {
+	var result = matrix.Copy()
+
+	for row := uint32(0); row < result.Rows; row = row + 1 {
+		result.ScaleRow(row, value)
+	}
+
+	return result
+}
+
+func (matrix *MatrixRat) ScaleRow(row uint32, value *big.Rat) {
+	for col := uint32(0); col < matrix.Cols; col = col + 1 {
+		matrix.data[row][col].Mul(matrix.data[row][col], value)
+	}
+}
+
+func (matrix *MatrixRat) AddRowToRow(fromRow uint32, toRow uint32, scalar *big.Rat) {
+	var scaled = new(big.Rat)
+
+	for col := uint32(0); col < matrix.Cols; col = col + 1 {
+		matrix.data[toRow][col].Add(matrix.data[toRow][col], scaled.Mul(matrix.data[fromRow][col], scalar))
+	}
+}
+
+func (matrix *MatrixRat) Copy() *MatrixRat {
+	var result = NewMatrixRat(matrix.Rows, matrix.Cols)
+
+	for row := uint32(0); row < result.Rows; row = row + 1 {
+		for col := uint32(0); col < result.Cols; col = col + 1 {
+			result.data[row][col].Set(matrix.data[row][col])
+		}
+	}
+
+	return result
+}
+
+func (matrix *MatrixRat) String() string {
+	var result strings.Builder
+	matrix.BuildString(&result, "")
+	return result.String()
+}
+
+func (matrix *MatrixRat) BuildString(stringBuilder *strings.Builder, indent string) {
+	stringBuilder.WriteString(indent + "Matrix " + strconv.Itoa(int(matrix.Rows)) + "x" + strconv.Itoa(int(matrix.Cols)) + "\n")
+
+	for row := uint32(0); row < matrix.Rows; row = row + 1 {
+		stringBuilder.WriteString(indent)
+		stringBuilder.WriteString("|")
+		for col := uint32(0); col < matrix.Cols; col = col + 1 {
+			var asString = matrix.data[row][col].RatString()
+
+			for index := len(asString); index < 8; index = index + 1 {
+				stringBuilder.WriteString(" ")
+			}
+			stringBuilder.WriteString(asString + " ")
+		}
+		stringBuilder.WriteString("|\n")
+	}
+}
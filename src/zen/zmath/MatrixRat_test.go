@@ -0,0 +1,62 @@
+package zmath
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+func checkMatrixRat(t *testing.T, actual *MatrixRat, expected []*big.Rat) {
+	for row := uint32(0); row < actual.Rows; row = row + 1 {
+		for col := uint32(0); col < actual.Cols; col = col + 1 {
+			var actualVal = actual.GetEntry(row, col)
+			var expectedVal = expected[row*actual.Cols+col]
+			if actualVal.Cmp(expectedVal) != 0 {
+				t.Errorf("Expected %s to equal %s at %d %d", expectedVal.RatString(), actualVal.RatString(), row, col)
+			}
+		}
+	}
+}
+
+func TestMatrixRatResize(t *testing.T) {
+	var mat = NewMatrixRat(1, 1)
+	mat.InitializeIdentity()
+	mat.SetEntry(0, 0, big.NewRat(3, 1))
+	mat.Resize(2, 2)
+
+	checkMatrixRat(t, mat, []*big.Rat{
+		big.NewRat(3, 1), big.NewRat(0, 1),
+		big.NewRat(0, 1), big.NewRat(1, 1),
+	})
+}
+
+func TestMatrixRatRowOperations(t *testing.T) {
+	var mat = NewMatrixRat(2, 2)
+	mat.InitializeIdentity()
+	mat.SetEntry(0, 1, big.NewRat(math.MaxInt64, 1))
+
+	mat.AddRowToRow(0, 1, big.NewRat(2, 1))
+	mat.ScaleRow(0, big.NewRat(1, 2))
+
+	var large = new(big.Rat).SetInt64(math.MaxInt64)
+
+	checkMatrixRat(t, mat, []*big.Rat{
+		big.NewRat(1, 2), new(big.Rat).Quo(large, big.NewRat(2, 1)),
+		big.NewRat(2, 1), new(big.Rat).Add(new(big.Rat).Add(large, large), big.NewRat(1, 1)),
+	})
+
+	var vector = NewMatrixRat(2, 1)
+	vector.SetEntry(0, 0, big.NewRat(1, 1))
+	vector.SetEntry(1, 0, big.NewRat(-1, 1))
+
+	product, err := mat.Mul(vector)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkMatrixRat(t, product, []*big.Rat{
+		new(big.Rat).Sub(big.NewRat(1, 2), new(big.Rat).Quo(large, big.NewRat(2, 1))),
+		new(big.Rat).Sub(big.NewRat(1, 1), new(big.Rat).Add(large, large)),
+	})
+}
@@ -1,6 +1,7 @@
 package zmath
 
 import (
+	"math"
 	"strconv"
 )
 
@@ -88,11 +89,11 @@ func (number RationalNumberi64) SimplifyRi64() RationalNumberi64 {
 }
 
 func (number RationalNumberi64) IsZero() bool {
-	return number.Numerator == 0
+	return number.Numerator == 0 && !number.IsNaN()
 }
 
 func (number RationalNumberi64) IsOne() bool {
-	return number.Numerator == number.Denominator
+	return number.Numerator == number.Denominator && !number.IsNaN()
 }
 
 func (number RationalNumberi64) ToString() string {
@@ -118,39 +119,78 @@ func AbsRi64(a RationalNumberi64) RationalNumberi64 {
 	}
 }
 
+// Values that don't fit in 64 bits become NaN instead of wrapping. The
+// smallest int64 is left out so every value can be negated
+func AddCheckedi64(a int64, b int64) (result int64, ok bool) {
+	result = a + b
+	return result, (result > a) == (b > 0) && result != math.MinInt64
+}
+
+func MulCheckedi64(a int64, b int64) (result int64, ok bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+
+	result = a * b
+	return result, result/b == a && a != math.MinInt64 && b != math.MinInt64 && result != math.MinInt64
+}
+
+func NaNRi64() RationalNumberi64 {
+	return RationalNumberi64{0, 0}
+}
+
+func (number RationalNumberi64) IsNaN() bool {
+	return number.Denominator == 0
+}
+
 func AddRi64(a RationalNumberi64, b RationalNumberi64) RationalNumberi64 {
+	if a.IsNaN() || b.IsNaN() {
+		return NaNRi64()
+	}
+
+	left, leftOk := MulCheckedi64(a.Numerator, b.Denominator)
+	right, rightOk := MulCheckedi64(b.Numerator, a.Denominator)
+	numerator, numeratorOk := AddCheckedi64(left, right)
+	denominator, denominatorOk := MulCheckedi64(a.Denominator, b.Denominator)
+
+	if !leftOk || !rightOk || !numeratorOk || !denominatorOk {
+		return NaNRi64()
+	}
+
 	return RationalNumberi64{
-		a.Numerator*b.Denominator + b.Numerator*a.Denominator,
-		a.Denominator * b.Denominator,
+		numerator,
+		denominator,
 	}
 }
 
 func SubRi64(a RationalNumberi64, b RationalNumberi64) RationalNumberi64 {
-	return RationalNumberi64{
-		a.Numerator*b.Denominator - b.Numerator*a.Denominator,
-		a.Denominator * b.Denominator,
-	}
+	return AddRi64(a, NegateRi64(b))
 }
 
 func MulRi64(a RationalNumberi64, b RationalNumberi64) RationalNumberi64 {
+	if a.IsNaN() || b.IsNaN() {
+		return NaNRi64()
+	}
+
+	numerator, numeratorOk := MulCheckedi64(a.Numerator, b.Numerator)
+	denominator, denominatorOk := MulCheckedi64(a.Denominator, b.Denominator)
+
+	if !numeratorOk || !denominatorOk {
+		return NaNRi64()
+	}
+
 	return RationalNumberi64{
-		a.Numerator * b.Numerator,
-		a.Denominator * b.Denominator,
+		numerator,
+		denominator,
 	}
 }
 
 func Muli64(a RationalNumberi64, scalar int64) RationalNumberi64 {
-	return RationalNumberi64{
-		a.Numerator * scalar,
-		a.Denominator,
-	}
+	return MulRi64(a, Ri64Fromi64(scalar))
 }
 
 func DivRi64(a RationalNumberi64, b RationalNumberi64) RationalNumberi64 {
-	return RationalNumberi64{
-		a.Numerator * b.Denominator,
-		a.Denominator * b.Numerator,
-	}
+	return MulRi64(a, InvRi64(b))
 }
 
 func InvRi64(a RationalNumberi64) RationalNumberi64 {
@@ -1,7 +1,9 @@
 package zmath
 
 import (
+	"math"
 	"testing"
+	"zen/test"
 )
 
 func checkValue(t *testing.T, actual RationalNumberi64, expected RationalNumberi64) {
@@ -16,3 +18,13 @@ func TestAddition(t *testing.T) {
 
 	checkValue(t, AddRi64(one, two), Ri64Fromi64(3))
 }
+
+func TestOverflow(t *testing.T) {
+	var large = Ri64Fromi64(math.MaxInt64)
+
+	test.Assert(t, AddRi64(large, Ri64_1()).IsNaN(), "Addition past the largest value should overflow")
+	test.Assert(t, MulRi64(large, Ri64Fromi64(2)).IsNaN(), "Multiplication past the largest value should overflow")
+	test.Assert(t, SubRi64(NegateRi64(large), Ri64_1()).IsNaN(), "The smallest int64 can't be negated so it is left out")
+	test.Assert(t, !AddRi64(NaNRi64(), Ri64_0()).IsZero(), "NaN should stay NaN")
+	checkValue(t, SubRi64(large, Ri64_1()), Ri64Fromi64(math.MaxInt64-1))
+}